@@ -0,0 +1,441 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "sort"
+    "sync"
+    "time"
+)
+
+// 已发出 bind 但尚未在 watch 中确认的 pod 的保留时间
+const assumeTTL = 30 * time.Second
+
+// clusterCache 通过 List/Watch 在内存中维护节点、pod、PVC 与 PV，
+// 并增量维护每个节点上 pod 已用资源，调度时不再重复请求 apiserver
+type clusterCache struct {
+    mu    sync.RWMutex
+    nodes map[string]*Node
+    pods  map[string]*Pod
+    used  map[string]ResourceUsage
+    ports map[string]hostPorts
+
+    // claims 以 namespace/name 为键，volumes 以 PV 名为键
+    claims  map[string]*PersistentVolumeClaim
+    volumes map[string]*PersistentVolume
+
+    // assumed 记录已假定调度到节点上的 pod 及其过期时间
+    assumed map[string]time.Time
+    // invalid 记录资源声明无法解析、未计入用量的 pod，避免重复告警
+    invalid map[string]bool
+
+    nodesSynced   chan struct{}
+    podsSynced    chan struct{}
+    claimsSynced  chan struct{}
+    volumesSynced chan struct{}
+}
+
+// clusterSnapshot 是某一时刻集群状态的只读副本，过滤和打分阶段共用同一份
+type clusterSnapshot struct {
+    nodes []*Node
+    pods  []*Pod
+    used  map[string]ResourceUsage
+    ports map[string]hostPorts
+
+    claims  map[string]*PersistentVolumeClaim
+    volumes map[string]*PersistentVolume
+
+    // state 缓存本轮调度中插件计算的中间结果
+    state map[string]cycleValue
+}
+
+var cache = newClusterCache()
+
+func newClusterCache() *clusterCache {
+    return &clusterCache{
+        nodes:         make(map[string]*Node),
+        pods:          make(map[string]*Pod),
+        used:          make(map[string]ResourceUsage),
+        ports:         make(map[string]hostPorts),
+        claims:        make(map[string]*PersistentVolumeClaim),
+        volumes:       make(map[string]*PersistentVolume),
+        assumed:       make(map[string]time.Time),
+        invalid:       make(map[string]bool),
+        nodesSynced:   make(chan struct{}),
+        podsSynced:    make(chan struct{}),
+        claimsSynced:  make(chan struct{}),
+        volumesSynced: make(chan struct{}),
+    }
+}
+
+func podKey(pod *Pod) string {
+    return podNamespace(pod) + "/" + pod.Metadata.Name
+}
+
+func claimKey(pvc *PersistentVolumeClaim) string {
+    namespace := pvc.Metadata.Namespace
+    if namespace == "" {
+        namespace = "default"
+    }
+    return namespace + "/" + pvc.Metadata.Name
+}
+
+func (ru ResourceUsage) add(o ResourceUsage) {
+    for name, quantity := range o {
+        ru[name] += quantity
+    }
+}
+
+func (ru ResourceUsage) sub(o ResourceUsage) {
+    for name, quantity := range o {
+        ru[name] -= quantity
+    }
+}
+
+func (ru ResourceUsage) clone() ResourceUsage {
+    c := make(ResourceUsage, len(ru))
+    c.add(ru)
+    return c
+}
+
+// run 启动节点、pod、PVC 和 PV 的 List/Watch，直到 done 关闭
+func (c *clusterCache) run(done chan struct{}, wg *sync.WaitGroup) {
+    errc := make(chan error, 1)
+
+    var nodesOnce, podsOnce, claimsOnce, volumesOnce sync.Once
+    nodes := &listWatcher{
+        name: "nodes",
+        path: nodesEndpoint,
+        list: func() (string, error) {
+            nodeList, err := getNodes()
+            if err != nil {
+                return "", err
+            }
+            c.replaceNodes(nodeList.Items)
+            nodesOnce.Do(func() { close(c.nodesSynced) })
+            return nodeList.Metadata.ResourceVersion, nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            var node Node
+            if err := json.Unmarshal(object, &node); err != nil {
+                return err
+            }
+            c.mu.Lock()
+            defer c.mu.Unlock()
+            if eventType == "DELETED" {
+                delete(c.nodes, node.Metadata.Name)
+            } else {
+                c.nodes[node.Metadata.Name] = &node
+            }
+            return nil
+        },
+    }
+
+    pods := &listWatcher{
+        name:  "pods",
+        path:  podsEndpoint,
+        query: activePodsQuery(),
+        list: func() (string, error) {
+            podList, err := getPods()
+            if err != nil {
+                return "", err
+            }
+            c.replacePods(podList.Items)
+            podsOnce.Do(func() { close(c.podsSynced) })
+            return podList.Metadata.ResourceVersion, nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            var pod Pod
+            if err := json.Unmarshal(object, &pod); err != nil {
+                return err
+            }
+            c.mu.Lock()
+            defer c.mu.Unlock()
+            key := podKey(&pod)
+            if _, ok := c.assumed[key]; ok {
+                // 尚未看到绑定结果的更新不能覆盖假定的节点
+                if eventType != "DELETED" && pod.Spec.NodeName == "" {
+                    return nil
+                }
+                delete(c.assumed, key)
+            }
+            c.removePod(key)
+            if eventType == "DELETED" {
+                delete(c.invalid, key)
+            } else {
+                c.addPod(&pod)
+            }
+            return nil
+        },
+    }
+
+    claims := &listWatcher{
+        name: "persistentvolumeclaims",
+        path: pvcsEndpoint,
+        list: func() (string, error) {
+            pvcList, err := getPersistentVolumeClaims()
+            if err != nil {
+                return "", err
+            }
+            c.replaceClaims(pvcList.Items)
+            claimsOnce.Do(func() { close(c.claimsSynced) })
+            return pvcList.Metadata.ResourceVersion, nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            var pvc PersistentVolumeClaim
+            if err := json.Unmarshal(object, &pvc); err != nil {
+                return err
+            }
+            c.mu.Lock()
+            defer c.mu.Unlock()
+            if eventType == "DELETED" {
+                delete(c.claims, claimKey(&pvc))
+            } else {
+                c.claims[claimKey(&pvc)] = &pvc
+            }
+            return nil
+        },
+    }
+
+    volumes := &listWatcher{
+        name: "persistentvolumes",
+        path: pvsEndpoint,
+        list: func() (string, error) {
+            pvList, err := getPersistentVolumes()
+            if err != nil {
+                return "", err
+            }
+            c.replaceVolumes(pvList.Items)
+            volumesOnce.Do(func() { close(c.volumesSynced) })
+            return pvList.Metadata.ResourceVersion, nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            var pv PersistentVolume
+            if err := json.Unmarshal(object, &pv); err != nil {
+                return err
+            }
+            c.mu.Lock()
+            defer c.mu.Unlock()
+            if eventType == "DELETED" {
+                delete(c.volumes, pv.Metadata.Name)
+            } else {
+                c.volumes[pv.Metadata.Name] = &pv
+            }
+            return nil
+        },
+    }
+
+    go nodes.run(done, errc)
+    go pods.run(done, errc)
+    go claims.run(done, errc)
+    go volumes.run(done, errc)
+
+    for {
+        select {
+        case err := <-errc:
+            log.Println(err)
+        case <-done:
+            wg.Done()
+            log.Println("Stopped cluster cache.")
+            return
+        }
+    }
+}
+
+// waitForSync 阻塞直到所有资源均完成首次 List，done 关闭时返回 false
+func (c *clusterCache) waitForSync(done chan struct{}) bool {
+    for _, synced := range []chan struct{}{c.nodesSynced, c.podsSynced, c.claimsSynced, c.volumesSynced} {
+        select {
+        case <-synced:
+        case <-done:
+            return false
+        }
+    }
+    return true
+}
+
+func (c *clusterCache) replaceNodes(items []*Node) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.nodes = make(map[string]*Node)
+    for _, node := range items {
+        c.nodes[node.Metadata.Name] = node
+    }
+}
+
+func (c *clusterCache) replaceClaims(items []PersistentVolumeClaim) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.claims = make(map[string]*PersistentVolumeClaim)
+    for i := range items {
+        c.claims[claimKey(&items[i])] = &items[i]
+    }
+}
+
+func (c *clusterCache) replaceVolumes(items []PersistentVolume) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.volumes = make(map[string]*PersistentVolume)
+    for i := range items {
+        c.volumes[items[i].Metadata.Name] = &items[i]
+    }
+}
+
+func (c *clusterCache) replacePods(items []Pod) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    old := c.pods
+    c.pods = make(map[string]*Pod)
+    c.used = make(map[string]ResourceUsage)
+    c.ports = make(map[string]hostPorts)
+    for i := range items {
+        c.addPod(&items[i])
+    }
+
+    // 列表中已绑定的假定 pod 视为确认，其余继续保留
+    for key := range c.assumed {
+        if pod, ok := c.pods[key]; ok && pod.Spec.NodeName != "" {
+            delete(c.assumed, key)
+            continue
+        }
+        if pod, ok := old[key]; ok {
+            c.removePod(key)
+            c.addPod(pod)
+        }
+    }
+}
+
+// assumePod 在发出 bind 请求前把 pod 计入目标节点，避免下一个 pod 在
+// watch 确认之前重复占用同一份资源
+func (c *clusterCache) assumePod(pod *Pod, nodeName string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    assumed := *pod
+    assumed.Spec.NodeName = nodeName
+    key := podKey(pod)
+    c.removePod(key)
+    c.addPod(&assumed)
+    c.assumed[key] = time.Now().Add(assumeTTL)
+}
+
+// forgetPod 在 bind 失败时撤销假定，pod 恢复为未调度的状态
+func (c *clusterCache) forgetPod(pod *Pod) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    key := podKey(pod)
+    if _, ok := c.assumed[key]; !ok {
+        return
+    }
+    delete(c.assumed, key)
+    c.removePod(key)
+
+    pending := *pod
+    pending.Spec.NodeName = ""
+    c.addPod(&pending)
+}
+
+func (c *clusterCache) isAssumed(pod *Pod) bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    _, ok := c.assumed[podKey(pod)]
+    return ok
+}
+
+// expireAssumed 清理超时仍未确认的假定 pod，需在持有写锁时调用
+func (c *clusterCache) expireAssumed(now time.Time) {
+    for key, deadline := range c.assumed {
+        if now.Before(deadline) {
+            continue
+        }
+        log.Printf("assumed pod %s expired without binding confirmation", key)
+        delete(c.assumed, key)
+        c.removePod(key)
+    }
+}
+
+// addPod 和 removePod 需在持有写锁时调用
+func (c *clusterCache) addPod(pod *Pod) {
+    // 已结束的 pod 不再占用节点资源
+    if isTerminated(pod) {
+        return
+    }
+    c.pods[podKey(pod)] = pod
+    if pod.Spec.NodeName == "" {
+        return
+    }
+
+    hp, ok := c.ports[pod.Spec.NodeName]
+    if !ok {
+        hp = hostPorts{}
+        c.ports[pod.Spec.NodeName] = hp
+    }
+    hp.add(pod)
+
+    usage, err := requestedResource(pod)
+    if err != nil {
+        key := podKey(pod)
+        if !c.invalid[key] {
+            c.invalid[key] = true
+            log.Printf("Warning: excluding pod from node usage: %v", err)
+            go postEvent(newPodEvent(pod, "Warning", "InvalidResources", err.Error()))
+        }
+        return
+    }
+    ru, ok := c.used[pod.Spec.NodeName]
+    if !ok {
+        ru = ResourceUsage{}
+        c.used[pod.Spec.NodeName] = ru
+    }
+    ru.add(usage)
+}
+
+func (c *clusterCache) removePod(key string) {
+    pod, ok := c.pods[key]
+    if !ok {
+        return
+    }
+    delete(c.pods, key)
+    if hp, ok := c.ports[pod.Spec.NodeName]; ok {
+        hp.remove(pod)
+    }
+    if ru, ok := c.used[pod.Spec.NodeName]; ok {
+        // 无法解析的 pod 从未计入用量
+        if usage, err := requestedResource(pod); err == nil {
+            ru.sub(usage)
+        }
+    }
+}
+
+func (c *clusterCache) snapshot() *clusterSnapshot {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.expireAssumed(time.Now())
+
+    s := &clusterSnapshot{
+        used:    make(map[string]ResourceUsage),
+        ports:   make(map[string]hostPorts),
+        claims:  make(map[string]*PersistentVolumeClaim, len(c.claims)),
+        volumes: make(map[string]*PersistentVolume, len(c.volumes)),
+    }
+    for name, node := range c.nodes {
+        s.nodes = append(s.nodes, node)
+        s.used[name] = c.used[name].clone()
+        s.ports[name] = c.ports[name].clone()
+    }
+    sort.Slice(s.nodes, func(i, j int) bool {
+        return s.nodes[i].Metadata.Name < s.nodes[j].Metadata.Name
+    })
+    for _, pod := range c.pods {
+        s.pods = append(s.pods, pod)
+    }
+    // watch 事件整体替换对象，快照只需复制映射
+    for key, pvc := range c.claims {
+        s.claims[key] = pvc
+    }
+    for name, pv := range c.volumes {
+        s.volumes[name] = pv
+    }
+    return s
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+)
+
+// useDefaultProfile 让调度器以默认插件负责 hightower
+func useDefaultProfile(t *testing.T) {
+    oldNames, oldProfiles := schedulerNames, profiles
+    t.Cleanup(func() { schedulerNames, profiles = oldNames, oldProfiles })
+
+    schedulerNames = []string{"hightower"}
+    var err error
+    if profiles, err = defaultProfiles(schedulerNames); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func pendingPod(namespace, name, cpu string) *Pod {
+    pod := &Pod{}
+    pod.Metadata.Name = name
+    pod.Metadata.Namespace = namespace
+    pod.Spec.SchedulerName = "hightower"
+    pod.Spec.Containers = []Container{{Name: "app", Resources: ResourceRequirements{Requests: ResourceList{resourceCPU: cpu}}}}
+    return pod
+}
+
+const oneNode = `{"metadata": {"resourceVersion": "1"}, "items": [
+    {"metadata": {"name": "node-1"}, "status": {"allocatable": {"cpu": "4", "memory": "8Gi", "pods": "110"}}}
+]}`
+
+func TestSchedulePodKeepsAssumptionWhenEventFails(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, oneNode)
+    f.set(podsEndpoint, emptyList)
+    f.fail("/api/v1/namespaces/default/events", http.StatusInternalServerError)
+    startCache(t)
+    useDefaultProfile(t)
+
+    pod := pendingPod("default", "web", "1")
+    if err := schedulePod(pod); err != nil {
+        t.Fatalf("schedulePod() = %v, want nil once the binding succeeded", err)
+    }
+    if !cache.isAssumed(pod) {
+        t.Error("pod is no longer assumed after a failed Scheduled event")
+    }
+    if used := cache.snapshot().used["node-1"][resourceCPU]; used != 1000 {
+        t.Errorf("node-1 cpu used = %d, want 1000", used)
+    }
+}
+
+func TestSchedulePodForgetsAssumptionWhenBindFails(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, oneNode)
+    f.set(podsEndpoint, emptyList)
+    f.fail("/api/v1/namespaces/default/pods/web/binding/", http.StatusConflict)
+    startCache(t)
+    useDefaultProfile(t)
+
+    pod := pendingPod("default", "web", "1")
+    cache.mu.Lock()
+    cache.addPod(pod)
+    cache.mu.Unlock()
+
+    if err := schedulePod(pod); err == nil {
+        t.Fatal("schedulePod() = nil, want the binding error")
+    }
+    if cache.isAssumed(pod) {
+        t.Error("pod is still assumed after the binding failed")
+    }
+
+    snapshot := cache.snapshot()
+    if used := snapshot.used["node-1"][resourceCPU]; used != 0 {
+        t.Errorf("node-1 cpu used = %d, want 0", used)
+    }
+    cache.mu.RLock()
+    restored, ok := cache.pods[podKey(pod)]
+    cache.mu.RUnlock()
+    if !ok || restored.Spec.NodeName != "" {
+        t.Errorf("pending pod not restored after forgetPod: %+v", restored)
+    }
+}
+
+func TestCacheExcludesTerminatedPods(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, oneNode)
+    // fakeAPI 不处理 fieldSelector，已结束的 pod 即使被返回也不能计入用量
+    f.set(podsEndpoint, `{"metadata": {"resourceVersion": "5"}, "items": [
+        {"metadata": {"name": "running", "namespace": "default"}, "spec": {"nodeName": "node-1",
+            "containers": [{"name": "app", "resources": {"requests": {"cpu": "1"}}}]}, "status": {"phase": "Running"}},
+        {"metadata": {"name": "pending", "namespace": "default"}, "spec": {"nodeName": "node-1",
+            "containers": [{"name": "app", "resources": {"requests": {"cpu": "200m"}}}]}, "status": {"phase": "Pending"}},
+        {"metadata": {"name": "done", "namespace": "default"}, "spec": {"nodeName": "node-1",
+            "containers": [{"name": "app", "resources": {"requests": {"cpu": "2"}}}]}, "status": {"phase": "Succeeded"}},
+        {"metadata": {"name": "crashed", "namespace": "default"}, "spec": {"nodeName": "node-1",
+            "containers": [{"name": "app", "resources": {"requests": {"cpu": "3"}}}]}, "status": {"phase": "Failed"}}
+    ]}`)
+    // running 在 watch 中结束后释放资源
+    f.watch = func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == podsEndpoint && r.URL.Query().Get("resourceVersion") == "5" {
+            fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"name": "running", "namespace": "default", "resourceVersion": "6"},
+                "spec": {"nodeName": "node-1", "containers": [{"name": "app", "resources": {"requests": {"cpu": "1"}}}]},
+                "status": {"phase": "Succeeded"}}}`)
+            w.(http.Flusher).Flush()
+        }
+        <-r.Context().Done()
+    }
+
+    startCache(t)
+
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        snapshot := cache.snapshot()
+        if snapshot.used["node-1"][resourceCPU] == 200 && len(snapshot.pods) == 1 {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("node-1 cpu used = %d with %d pods, want 200 from the pending pod only",
+                snapshot.used["node-1"][resourceCPU], len(snapshot.pods))
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+
+    requests := f.requestsTo(http.MethodGet, podsEndpoint)
+    if len(requests) < 2 {
+        t.Fatalf("got %d pod requests, want a list and a watch", len(requests))
+    }
+    for _, r := range requests {
+        if got := r.query["fieldSelector"]; len(got) != 1 || got[0] != "status.phase!=Succeeded,status.phase!=Failed" {
+            t.Errorf("pods request %v fieldSelector = %q, want a single status.phase selector", r.query, got)
+        }
+    }
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
+)
+
+// schedulerConfig 是 -config 指定的调度器配置文件（YAML 或 JSON），
+// 每个 profile 对应一个 schedulerName，可以启用不同的插件、权重和参数
+type schedulerConfig struct {
+    Profiles []profileConfig `json:"profiles"`
+}
+
+type profileConfig struct {
+    SchedulerName string         `json:"schedulerName"`
+    Plugins       *pluginsConfig `json:"plugins,omitempty"`
+    PluginConfig  []pluginArgs   `json:"pluginConfig,omitempty"`
+}
+
+// pluginsConfig 中未列出的扩展点沿用 defaultPlugins
+type pluginsConfig struct {
+    Filter []string            `json:"filter,omitempty"`
+    Score  []scorePluginConfig `json:"score,omitempty"`
+}
+
+type scorePluginConfig struct {
+    Name string `json:"name"`
+    // Weight 为 0 时按 1 处理
+    Weight int64 `json:"weight,omitempty"`
+}
+
+type pluginArgs struct {
+    Name string          `json:"name"`
+    Args json.RawMessage `json:"args,omitempty"`
+}
+
+// loadSchedulerConfig 读取配置文件，按文件中的顺序返回 schedulerName 及对应的调度框架
+func loadSchedulerConfig(path string) ([]string, map[string]*framework, error) {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    var config schedulerConfig
+    if err := kube.UnmarshalYAML(data, &config); err != nil {
+        return nil, nil, fmt.Errorf("failed to parse scheduler config %s: %v", path, err)
+    }
+    if len(config.Profiles) == 0 {
+        return nil, nil, fmt.Errorf("scheduler config %s: no profiles defined", path)
+    }
+
+    var names []string
+    frameworks := make(map[string]*framework)
+    for _, profile := range config.Profiles {
+        name := profile.SchedulerName
+        if name == "" {
+            return nil, nil, fmt.Errorf("scheduler config %s: profile without schedulerName", path)
+        }
+        if _, ok := frameworks[name]; ok {
+            return nil, nil, fmt.Errorf("scheduler config %s: duplicate profile %s", path, name)
+        }
+
+        fwk, err := newFramework(profilePluginSet(profile))
+        if err != nil {
+            return nil, nil, fmt.Errorf("scheduler config %s: profile %s: %v", path, name, err)
+        }
+        names = append(names, name)
+        frameworks[name] = fwk
+    }
+    return names, frameworks, nil
+}
+
+func profilePluginSet(profile profileConfig) pluginSet {
+    set := pluginSet{
+        filters: defaultPlugins.filters,
+        scores:  defaultPlugins.scores,
+        args:    make(map[string]json.RawMessage),
+    }
+    if profile.Plugins != nil {
+        if profile.Plugins.Filter != nil {
+            set.filters = profile.Plugins.Filter
+        }
+        if profile.Plugins.Score != nil {
+            set.scores = nil
+            for _, s := range profile.Plugins.Score {
+                weight := s.Weight
+                if weight == 0 {
+                    weight = 1
+                }
+                set.scores = append(set.scores, weightedPluginName{s.Name, weight})
+            }
+        }
+    }
+    for _, a := range profile.PluginConfig {
+        set.args[a.Name] = a.Args
+    }
+    return set
+}
+
+// defaultProfiles 在未指定配置文件时让每个 schedulerName 都使用 defaultPlugins
+func defaultProfiles(names []string) (map[string]*framework, error) {
+    fwk, err := newFramework(defaultPlugins)
+    if err != nil {
+        return nil, err
+    }
+    frameworks := make(map[string]*framework)
+    for _, name := range names {
+        frameworks[name] = fwk
+    }
+    return frameworks, nil
+}
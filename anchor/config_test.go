@@ -0,0 +1,154 @@
+package main
+
+import (
+    "fmt"
+    "io/ioutil"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func writeSchedulerConfig(t *testing.T, config string) string {
+    path := filepath.Join(t.TempDir(), "scheduler-config.yaml")
+    if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+// scoreWeights 以 "name=weight" 的形式列出框架中的打分插件
+func scoreWeights(fwk *framework) []string {
+    var weights []string
+    for _, p := range fwk.scores {
+        weights = append(weights, fmt.Sprintf("%s=%d", p.Name(), p.weight))
+    }
+    return weights
+}
+
+func TestLoadSampleSchedulerConfig(t *testing.T) {
+    names, frameworks, err := loadSchedulerConfig("../deployments/scheduler-config.yaml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{"hightower", "hightower-batch"}; !reflect.DeepEqual(names, want) {
+        t.Fatalf("schedulerNames = %v, want %v", names, want)
+    }
+
+    tests := []struct {
+        name   string
+        scores []string
+    }{
+        {"hightower", []string{"NodeResourcesLeastAllocated=2", "NodeResourcesBalancedAllocation=1", "TaintToleration=1", "InterPodAffinity=1", "PodTopologySpread=2"}},
+        {"hightower-batch", []string{"NodeResourcesMostAllocated=2", "NodeResourcesBalancedAllocation=1", "TaintToleration=1"}},
+    }
+    for _, tt := range tests {
+        fwk := frameworks[tt.name]
+        if got := scoreWeights(fwk); !reflect.DeepEqual(got, tt.scores) {
+            t.Errorf("%s: scores = %v, want %v", tt.name, got, tt.scores)
+        }
+        // 示例未配置 filter，沿用 defaultPlugins
+        if len(fwk.filters) != len(defaultPlugins.filters) {
+            t.Errorf("%s: %d filters, want the %d default filters", tt.name, len(fwk.filters), len(defaultPlugins.filters))
+        }
+    }
+}
+
+func TestLoadSchedulerConfigErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        config string
+        err    string
+    }{
+        {"no profiles", "profiles: []", "no profiles defined"},
+        {"duplicate profile", `profiles:
+  - schedulerName: hightower
+  - schedulerName: hightower
+`, "duplicate profile hightower"},
+        {"missing schedulerName", `profiles:
+  - plugins:
+      filter: [NodeResourcesFit]
+`, "profile without schedulerName"},
+        {"args for disabled plugin", `profiles:
+  - schedulerName: hightower
+    plugins:
+      score:
+        - name: NodeResourcesLeastAllocated
+    pluginConfig:
+      - name: NodeResourcesMostAllocated
+        args:
+          resources:
+            - name: cpu
+              weight: 1
+`, "arguments given for plugin NodeResourcesMostAllocated which is not enabled"},
+        {"unknown plugin", `profiles:
+  - schedulerName: hightower
+    plugins:
+      filter: [NodeResourcesFits]
+`, "unknown plugin NodeResourcesFits"},
+        {"negative weight", `profiles:
+  - schedulerName: hightower
+    plugins:
+      score:
+        - name: NodeResourcesLeastAllocated
+          weight: -1
+`, "weight must be positive"},
+    }
+    for _, tt := range tests {
+        _, _, err := loadSchedulerConfig(writeSchedulerConfig(t, tt.config))
+        if err == nil || !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("%s: loadSchedulerConfig() = %v, want error containing %q", tt.name, err, tt.err)
+        }
+    }
+}
+
+func TestProfilePluginSet(t *testing.T) {
+    tests := []struct {
+        name    string
+        profile profileConfig
+        filters []string
+        scores  []weightedPluginName
+    }{
+        {"no plugins", profileConfig{SchedulerName: "a"}, defaultPlugins.filters, defaultPlugins.scores},
+        // 未列出的扩展点沿用 defaultPlugins
+        {"score only", profileConfig{Plugins: &pluginsConfig{Score: []scorePluginConfig{{Name: "NodeResourcesMostAllocated", Weight: 3}}}},
+            defaultPlugins.filters, []weightedPluginName{{"NodeResourcesMostAllocated", 3}}},
+        {"filter only", profileConfig{Plugins: &pluginsConfig{Filter: []string{"NodeResourcesFit"}}},
+            []string{"NodeResourcesFit"}, defaultPlugins.scores},
+        {"weight 0 falls back to 1", profileConfig{Plugins: &pluginsConfig{Score: []scorePluginConfig{{Name: "NodeResourcesLeastAllocated"}, {Name: "NodePrice", Weight: 2}}}},
+            defaultPlugins.filters, []weightedPluginName{{"NodeResourcesLeastAllocated", 1}, {"NodePrice", 2}}},
+        // 显式的空列表表示关闭该扩展点
+        {"empty filter list", profileConfig{Plugins: &pluginsConfig{Filter: []string{}}},
+            []string{}, defaultPlugins.scores},
+    }
+    for _, tt := range tests {
+        set := profilePluginSet(tt.profile)
+        if !reflect.DeepEqual(set.filters, tt.filters) {
+            t.Errorf("%s: filters = %v, want %v", tt.name, set.filters, tt.filters)
+        }
+        if !reflect.DeepEqual(set.scores, tt.scores) {
+            t.Errorf("%s: scores = %v, want %v", tt.name, set.scores, tt.scores)
+        }
+    }
+}
+
+func TestLoadSchedulerConfigEmptyFilterList(t *testing.T) {
+    _, frameworks, err := loadSchedulerConfig(writeSchedulerConfig(t, `profiles:
+  - schedulerName: hightower
+    plugins:
+      filter: []
+      score:
+        - name: NodeResourcesLeastAllocated
+          weight: 0
+`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    fwk := frameworks["hightower"]
+    if len(fwk.filters) != 0 {
+        t.Errorf("%d filters, want none", len(fwk.filters))
+    }
+    if got, want := scoreWeights(fwk), []string{"NodeResourcesLeastAllocated=1"}; !reflect.DeepEqual(got, want) {
+        t.Errorf("scores = %v, want %v", got, want)
+    }
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
+)
+
+// fakeRequest 是 fakeAPI 收到的一次请求
+type fakeRequest struct {
+    method string
+    path   string
+    query  url.Values
+    body   string
+}
+
+// fakeAPI 是测试用的 apiserver：GET 返回预置的 JSON，POST 记录请求并返回 201。
+// watch 请求默认等待片刻后返回空的事件流，设置 watch 后由测试自行应答
+type fakeAPI struct {
+    mu       sync.Mutex
+    objects  map[string]string
+    status   map[string]int
+    requests []fakeRequest
+    watch    func(w http.ResponseWriter, r *http.Request)
+}
+
+// newFakeAPI 启动 fakeAPI 并让全局 client 指向它，测试结束时恢复。
+// PVC 和 PV 默认为空列表
+func newFakeAPI(t *testing.T) *fakeAPI {
+    f := &fakeAPI{
+        objects: map[string]string{
+            pvcsEndpoint: emptyList,
+            pvsEndpoint:  emptyList,
+        },
+        status: make(map[string]int),
+    }
+    srv := httptest.NewServer(f)
+    u, err := url.Parse(srv.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    old := client
+    client = kube.NewClientForServer(u)
+    t.Cleanup(func() {
+        client = old
+        srv.Close()
+    })
+    return f
+}
+
+func (f *fakeAPI) set(path, body string) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.objects[path] = body
+}
+
+// fail 让 path 的请求返回 code
+func (f *fakeAPI) fail(path string, code int) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.status[path] = code
+}
+
+// requestsTo 返回发往 path 的请求，path 以 / 结尾时按前缀匹配
+func (f *fakeAPI) requestsTo(method, path string) []fakeRequest {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    var requests []fakeRequest
+    for _, r := range f.requests {
+        if r.method != method {
+            continue
+        }
+        if r.path == path || (strings.HasSuffix(path, "/") && strings.HasPrefix(r.path, path)) {
+            requests = append(requests, r)
+        }
+    }
+    return requests
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    body, _ := ioutil.ReadAll(r.Body)
+    f.mu.Lock()
+    f.requests = append(f.requests, fakeRequest{method: r.Method, path: r.URL.Path, query: r.URL.Query(), body: string(body)})
+    code, failed := f.status[r.URL.Path]
+    object, found := f.objects[r.URL.Path]
+    watch := f.watch
+    f.mu.Unlock()
+
+    if r.URL.Query().Get("watch") == "true" {
+        if watch != nil {
+            watch(w, r)
+            return
+        }
+        select {
+        case <-time.After(100 * time.Millisecond):
+        case <-r.Context().Done():
+        }
+        return
+    }
+
+    switch {
+    case failed:
+        w.WriteHeader(code)
+    case r.Method == http.MethodPost:
+        w.WriteHeader(http.StatusCreated)
+    case found:
+        w.Write([]byte(object))
+    default:
+        w.WriteHeader(http.StatusNotFound)
+    }
+}
+
+// startCache 用 fakeAPI 中的节点和 pod 启动一个新的 clusterCache 并等待同步
+func startCache(t *testing.T) {
+    old := cache
+    cache = newClusterCache()
+    done := make(chan struct{})
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go cache.run(done, &wg)
+    t.Cleanup(func() {
+        close(done)
+        wg.Wait()
+        cache = old
+    })
+
+    synced := make(chan struct{})
+    go func() {
+        cache.waitForSync(done)
+        close(synced)
+    }()
+    select {
+    case <-synced:
+    case <-time.After(5 * time.Second):
+        t.Fatal("cluster cache did not sync")
+    }
+}
+
+const emptyList = `{"metadata": {"resourceVersion": "1"}, "items": []}`
+
+// labeledNode 和 labeledPod 构造只带名字和标签的对象
+func labeledNode(name string, labels map[string]string) *Node {
+    node := &Node{}
+    node.Metadata.Name = name
+    node.Metadata.Labels = labels
+    return node
+}
+
+func labeledPod(namespace, name string, labels map[string]string) *Pod {
+    pod := &Pod{}
+    pod.Metadata.Namespace = namespace
+    pod.Metadata.Name = name
+    pod.Metadata.Labels = labels
+    return pod
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+)
+
+// 调度框架：过滤和打分由插件完成，predicate 和 priorities 只负责按顺序调用已启用的插件。
+// 新的调度策略只需实现 filterPlugin 或 scorePlugin 并通过 registerPlugin 注册
+
+// 插件打分的取值范围为 [0, maxNodeScore]
+const maxNodeScore int64 = 100
+
+const (
+    // statusUnschedulable 表示 pod 无法调度到该节点
+    statusUnschedulable = iota + 1
+    // statusError 表示插件内部出错，本轮调度终止
+    statusError
+)
+
+// pluginStatus 是插件的执行结果，nil 表示成功
+type pluginStatus struct {
+    code    int
+    message string
+}
+
+func unschedulable(err error) *pluginStatus {
+    return &pluginStatus{code: statusUnschedulable, message: err.Error()}
+}
+
+func pluginError(err error) *pluginStatus {
+    return &pluginStatus{code: statusError, message: err.Error()}
+}
+
+type plugin interface {
+    Name() string
+}
+
+// filterPlugin 判断 pod 能否调度到节点上
+type filterPlugin interface {
+    plugin
+    Filter(pod *Pod, info *nodeInfo) *pluginStatus
+}
+
+// scorePlugin 给通过过滤的节点打分
+type scorePlugin interface {
+    plugin
+    Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus)
+}
+
+// scoreNormalizer 可由 scorePlugin 选择实现，在所有节点打分后统一把得分调整到 [0, maxNodeScore]
+type scoreNormalizer interface {
+    NormalizeScore(pod *Pod, scores map[*Node]int64)
+}
+
+// scoreExplainer 可由 scorePlugin 选择实现，说明选中某个节点的理由，写入 Scheduled 事件
+type scoreExplainer interface {
+    Explain(pod *Pod, info *nodeInfo) string
+}
+
+// pluginFactory 根据配置文件中的参数创建插件，未配置参数时 args 为空
+type pluginFactory func(args json.RawMessage) (plugin, error)
+
+var pluginRegistry = map[string]pluginFactory{}
+
+// registerPlugin 注册插件，通常在插件所在文件的 init 中调用
+func registerPlugin(name string, factory pluginFactory) {
+    if _, ok := pluginRegistry[name]; ok {
+        panic(fmt.Sprintf("plugin %s registered twice", name))
+    }
+    pluginRegistry[name] = factory
+}
+
+// nodeInfo 是插件看到的单个节点的状态
+type nodeInfo struct {
+    node  *Node
+    used  ResourceUsage
+    ports hostPorts
+    // snapshot 供插件访问集群中的其他节点与 pod，以及本轮调度的缓存数据
+    snapshot *clusterSnapshot
+}
+
+func (s *clusterSnapshot) nodeInfo(node *Node) *nodeInfo {
+    return &nodeInfo{
+        node:     node,
+        used:     s.used[node.Metadata.Name],
+        ports:    s.ports[node.Metadata.Name],
+        snapshot: s,
+    }
+}
+
+func (s *clusterSnapshot) nodeInfos() []*nodeInfo {
+    infos := make([]*nodeInfo, 0, len(s.nodes))
+    for _, node := range s.nodes {
+        infos = append(infos, s.nodeInfo(node))
+    }
+    return infos
+}
+
+type cycleValue struct {
+    value interface{}
+    err   error
+}
+
+// cycleState 在一次调度中缓存 compute 的结果，快照只用于调度一个 pod，
+// 插件可以把与节点无关的计算（如 pod 请求的资源）按 key 缓存在这里
+func (s *clusterSnapshot) cycleState(key string, compute func() (interface{}, error)) (interface{}, error) {
+    if s.state == nil {
+        s.state = make(map[string]cycleValue)
+    }
+    if v, ok := s.state[key]; ok {
+        return v.value, v.err
+    }
+    value, err := compute()
+    s.state[key] = cycleValue{value: value, err: err}
+    return value, err
+}
+
+type weightedScorePlugin struct {
+    scorePlugin
+    weight int64
+}
+
+// framework 是一组按顺序执行的过滤插件和带权重的打分插件
+type framework struct {
+    filters []filterPlugin
+    scores  []weightedScorePlugin
+}
+
+type weightedPluginName struct {
+    name   string
+    weight int64
+}
+
+// pluginSet 列出启用的插件，按列出的顺序执行
+type pluginSet struct {
+    filters []string
+    scores  []weightedPluginName
+    // args 为各插件的参数，以插件名为键
+    args map[string]json.RawMessage
+}
+
+var defaultPlugins = pluginSet{
+    filters: []string{
+        "NodeAffinity",
+        "NodeConditions",
+        "TaintToleration",
+        "NodePorts",
+        "InterPodAffinity",
+        "PodTopologySpread",
+        "VolumeBinding",
+        "NodeVolumeLimits",
+        "NodeResourcesFit",
+    },
+    scores: []weightedPluginName{
+        {"NodeResourcesBalancedAllocation", 1},
+        {"NodeResourcesLeastAllocated", 1},
+        {"TaintToleration", 1},
+        {"InterPodAffinity", 1},
+        {"PodTopologySpread", 1},
+        {"NodePrice", 2},
+    },
+}
+
+// profiles 记录每个 schedulerName 使用的调度框架，main 中根据配置文件或 defaultPlugins 创建
+var profiles = map[string]*framework{}
+
+// newFramework 按名字从注册表中创建插件，同名插件只创建一次，过滤和打分共用同一实例
+func newFramework(set pluginSet) (*framework, error) {
+    f := &framework{}
+    instances := make(map[string]plugin)
+    get := func(name string) (plugin, error) {
+        if p, ok := instances[name]; ok {
+            return p, nil
+        }
+        factory, ok := pluginRegistry[name]
+        if !ok {
+            return nil, fmt.Errorf("unknown plugin %s", name)
+        }
+        p, err := factory(set.args[name])
+        if err != nil {
+            return nil, fmt.Errorf("plugin %s: %v", name, err)
+        }
+        instances[name] = p
+        return p, nil
+    }
+
+    for _, name := range set.filters {
+        p, err := get(name)
+        if err != nil {
+            return nil, err
+        }
+        filter, ok := p.(filterPlugin)
+        if !ok {
+            return nil, fmt.Errorf("plugin %s is not a filter plugin", name)
+        }
+        f.filters = append(f.filters, filter)
+    }
+
+    for _, w := range set.scores {
+        p, err := get(w.name)
+        if err != nil {
+            return nil, err
+        }
+        score, ok := p.(scorePlugin)
+        if !ok {
+            return nil, fmt.Errorf("plugin %s is not a score plugin", w.name)
+        }
+        if w.weight <= 0 {
+            return nil, fmt.Errorf("plugin %s: weight must be positive", w.name)
+        }
+        f.scores = append(f.scores, weightedScorePlugin{scorePlugin: score, weight: w.weight})
+    }
+
+    // 为未启用的插件配置参数多半是写错了名字
+    for name := range set.args {
+        if _, ok := instances[name]; !ok {
+            return nil, fmt.Errorf("arguments given for plugin %s which is not enabled", name)
+        }
+    }
+    return f, nil
+}
+
+// runFilterPlugins 依次执行过滤插件，返回第一个失败的结果
+func (f *framework) runFilterPlugins(pod *Pod, info *nodeInfo) *pluginStatus {
+    for _, p := range f.filters {
+        if status := p.Filter(pod, info); status != nil {
+            return status
+        }
+    }
+    return nil
+}
+
+// explain 汇总打分插件对选中节点的说明
+func (f *framework) explain(pod *Pod, info *nodeInfo) []string {
+    var reasons []string
+    for _, p := range f.scores {
+        if e, ok := p.scorePlugin.(scoreExplainer); ok {
+            if reason := e.Explain(pod, info); reason != "" {
+                reasons = append(reasons, reason)
+            }
+        }
+    }
+    return reasons
+}
+
+// runScorePlugins 返回各节点按权重平均后的得分。某个插件无法给节点打分时该节点被剔除，
+// 插件内部出错时返回错误
+func (f *framework) runScorePlugins(pod *Pod, infos []*nodeInfo) (map[*Node]float64, error) {
+    nodeScore := make(map[*Node]float64, len(infos))
+    for _, info := range infos {
+        nodeScore[info.node] = 0
+    }
+
+    var totalWeight int64
+    for _, p := range f.scores {
+        scores := make(map[*Node]int64, len(infos))
+        for _, info := range infos {
+            if _, ok := nodeScore[info.node]; !ok {
+                continue
+            }
+            score, status := p.Score(pod, info)
+            if status != nil {
+                if status.code == statusError {
+                    return nil, fmt.Errorf("%s: %s", p.Name(), status.message)
+                }
+                log.Printf("%s: node %s: %s", p.Name(), info.node.Metadata.Name, status.message)
+                delete(nodeScore, info.node)
+                continue
+            }
+            scores[info.node] = score
+        }
+        if n, ok := p.scorePlugin.(scoreNormalizer); ok {
+            n.NormalizeScore(pod, scores)
+        }
+        for node, score := range scores {
+            if _, ok := nodeScore[node]; ok {
+                nodeScore[node] += float64(p.weight * score)
+            }
+        }
+        totalWeight += p.weight
+    }
+
+    if totalWeight > 0 {
+        for node := range nodeScore {
+            nodeScore[node] /= float64(totalWeight)
+        }
+    }
+    return nodeScore, nil
+}
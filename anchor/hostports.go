@@ -0,0 +1,83 @@
+package main
+
+import "fmt"
+
+const defaultHostIP = "0.0.0.0"
+
+// hostPort 标识节点上被占用的 (hostIP, protocol, hostPort)
+type hostPort struct {
+    IP       string
+    Protocol string
+    Port     int32
+}
+
+// hostPorts 记录节点上各端口被多少个 pod 占用
+type hostPorts map[hostPort]int
+
+// podHostPorts 返回 pod 申请的所有 hostPort，hostIP 默认为 0.0.0.0，协议默认为 TCP
+func podHostPorts(pod *Pod) []hostPort {
+    var ports []hostPort
+    for _, c := range pod.Spec.Containers {
+        for _, p := range c.Ports {
+            if p.HostPort <= 0 {
+                continue
+            }
+            hp := hostPort{IP: p.HostIP, Protocol: p.Protocol, Port: p.HostPort}
+            if hp.IP == "" {
+                hp.IP = defaultHostIP
+            }
+            if hp.Protocol == "" {
+                hp.Protocol = "TCP"
+            }
+            ports = append(ports, hp)
+        }
+    }
+    return ports
+}
+
+func (hps hostPorts) add(pod *Pod) {
+    for _, hp := range podHostPorts(pod) {
+        hps[hp]++
+    }
+}
+
+func (hps hostPorts) remove(pod *Pod) {
+    for _, hp := range podHostPorts(pod) {
+        if hps[hp] <= 1 {
+            delete(hps, hp)
+        } else {
+            hps[hp]--
+        }
+    }
+}
+
+func (hps hostPorts) clone() hostPorts {
+    c := make(hostPorts, len(hps))
+    for hp, n := range hps {
+        c[hp] = n
+    }
+    return c
+}
+
+// conflicts 判断端口是否已被占用，0.0.0.0 与任意 IP 的同协议同端口冲突
+func (hps hostPorts) conflicts(want hostPort) bool {
+    for used := range hps {
+        if used.Protocol != want.Protocol || used.Port != want.Port {
+            continue
+        }
+        if used.IP == want.IP || used.IP == defaultHostIP || want.IP == defaultHostIP {
+            return true
+        }
+    }
+    return false
+}
+
+// fitsHostPorts 检查 pod 申请的 hostPort 在节点上是否可用
+func fitsHostPorts(pod *Pod, used hostPorts) error {
+    for _, hp := range podHostPorts(pod) {
+        if used.conflicts(hp) {
+            return fmt.Errorf("node didn't have free port %s/%d on %s", hp.Protocol, hp.Port, hp.IP)
+        }
+    }
+    return nil
+}
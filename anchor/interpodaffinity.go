@@ -0,0 +1,222 @@
+package main
+
+import "fmt"
+
+// matchLabelSelector 判断标签是否满足选择器，nil 选择器不匹配任何对象，空选择器匹配所有对象
+func matchLabelSelector(selector *LabelSelector, labels map[string]string) bool {
+    if selector == nil {
+        return false
+    }
+    for key, value := range selector.MatchLabels {
+        if actual, ok := labels[key]; !ok || actual != value {
+            return false
+        }
+    }
+    for _, req := range selector.MatchExpressions {
+        if req.Operator == selectorOpGt || req.Operator == selectorOpLt {
+            return false
+        }
+        value, ok := labels[req.Key]
+        matched, err := matchSelectorRequirement(NodeSelectorRequirement(req), value, ok)
+        if err != nil || !matched {
+            return false
+        }
+    }
+    return true
+}
+
+// podMatchesTerm 判断 target 是否被 owner 的亲和性 term 选中，
+// term 未指定 namespaces 时使用 owner 所在的 namespace
+func podMatchesTerm(target, owner *Pod, term PodAffinityTerm) bool {
+    namespaces := term.Namespaces
+    if len(namespaces) == 0 {
+        namespaces = []string{podNamespace(owner)}
+    }
+    if !containsString(namespaces, podNamespace(target)) {
+        return false
+    }
+    return matchLabelSelector(term.LabelSelector, target.Metadata.Labels)
+}
+
+// sameTopology 两个节点都带有 topologyKey 标签且取值相同
+func sameTopology(a, b *Node, topologyKey string) bool {
+    if a == nil || b == nil {
+        return false
+    }
+    va, ok := a.Metadata.Labels[topologyKey]
+    if !ok {
+        return false
+    }
+    vb, ok := b.Metadata.Labels[topologyKey]
+    return ok && va == vb
+}
+
+// scheduledPod 是已调度 pod 及其所在节点
+type scheduledPod struct {
+    pod  *Pod
+    node *Node
+}
+
+// scheduledPods 返回快照中已绑定到已知节点的 pod
+func (s *clusterSnapshot) scheduledPods() []scheduledPod {
+    nodes := make(map[string]*Node, len(s.nodes))
+    for _, node := range s.nodes {
+        nodes[node.Metadata.Name] = node
+    }
+    var pods []scheduledPod
+    for _, pod := range s.pods {
+        if node, ok := nodes[pod.Spec.NodeName]; ok {
+            pods = append(pods, scheduledPod{pod: pod, node: node})
+        }
+    }
+    return pods
+}
+
+func podAffinityTerms(pod *Pod) []PodAffinityTerm {
+    if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAffinity == nil {
+        return nil
+    }
+    return pod.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+func podAntiAffinityTerms(pod *Pod) []PodAffinityTerm {
+    if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+        return nil
+    }
+    return pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// fitsInterPodAffinity 检查 pod 的 required 亲和性与反亲和性，
+// 以及已有 pod 的 required 反亲和性（对称检查）
+func fitsInterPodAffinity(pod *Pod, node *Node, existing []scheduledPod) error {
+    // 已有 pod 的反亲和性不允许 pod 进入其所在拓扑域
+    for _, e := range existing {
+        for _, term := range podAntiAffinityTerms(e.pod) {
+            if podMatchesTerm(pod, e.pod, term) && sameTopology(node, e.node, term.TopologyKey) {
+                return fmt.Errorf("node would violate anti-affinity of existing pod %s", podKey(e.pod))
+            }
+        }
+    }
+
+    for _, term := range podAntiAffinityTerms(pod) {
+        for _, e := range existing {
+            if podMatchesTerm(e.pod, pod, term) && sameTopology(node, e.node, term.TopologyKey) {
+                return fmt.Errorf("node didn't satisfy pod anti-affinity rules: pod %s in the same %s", podKey(e.pod), term.TopologyKey)
+            }
+        }
+    }
+
+    terms := podAffinityTerms(pod)
+    if len(terms) == 0 {
+        return nil
+    }
+    for _, term := range terms {
+        if _, ok := node.Metadata.Labels[term.TopologyKey]; !ok {
+            return fmt.Errorf("node didn't satisfy pod affinity rules: missing topology label %s", term.TopologyKey)
+        }
+    }
+
+    satisfied := true
+    anyMatch := false
+    for _, term := range terms {
+        found := false
+        for _, e := range existing {
+            if !podMatchesTerm(e.pod, pod, term) {
+                continue
+            }
+            anyMatch = true
+            if sameTopology(node, e.node, term.TopologyKey) {
+                found = true
+                break
+            }
+        }
+        if !found {
+            satisfied = false
+        }
+    }
+    if satisfied {
+        return nil
+    }
+
+    // 集群中还没有任何匹配的 pod 且 pod 自身满足所有 term 时允许调度，
+    // 否则同一组中的第一个 pod 永远无法调度
+    if !anyMatch {
+        selfMatch := true
+        for _, term := range terms {
+            if !podMatchesTerm(pod, pod, term) {
+                selfMatch = false
+            }
+        }
+        if selfMatch {
+            return nil
+        }
+    }
+    return fmt.Errorf("node didn't satisfy pod affinity rules")
+}
+
+// preferredAffinityTerms 返回 pod 的 preferred 亲和性与反亲和性
+func preferredAffinityTerms(pod *Pod) (affinity, antiAffinity []WeightedPodAffinityTerm) {
+    if pod.Spec.Affinity == nil {
+        return nil, nil
+    }
+    if pod.Spec.Affinity.PodAffinity != nil {
+        affinity = pod.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+    }
+    if pod.Spec.Affinity.PodAntiAffinity != nil {
+        antiAffinity = pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+    }
+    return affinity, antiAffinity
+}
+
+// interPodAffinityRawScore 按 preferred 亲和性（加分）与反亲和性（减分）计算节点原始得分，
+// 已有 pod 的 preferred 规则同样对称计入，结果可能为负，需经 normalizeScores 归一化
+func interPodAffinityRawScore(pod *Pod, node *Node, existing []scheduledPod) int64 {
+    var raw int64
+    affinity, antiAffinity := preferredAffinityTerms(pod)
+    for _, e := range existing {
+        existingAffinity, existingAntiAffinity := preferredAffinityTerms(e.pod)
+        for _, w := range affinity {
+            if podMatchesTerm(e.pod, pod, w.PodAffinityTerm) && sameTopology(node, e.node, w.PodAffinityTerm.TopologyKey) {
+                raw += w.Weight
+            }
+        }
+        for _, w := range antiAffinity {
+            if podMatchesTerm(e.pod, pod, w.PodAffinityTerm) && sameTopology(node, e.node, w.PodAffinityTerm.TopologyKey) {
+                raw -= w.Weight
+            }
+        }
+        for _, w := range existingAffinity {
+            if podMatchesTerm(pod, e.pod, w.PodAffinityTerm) && sameTopology(node, e.node, w.PodAffinityTerm.TopologyKey) {
+                raw += w.Weight
+            }
+        }
+        for _, w := range existingAntiAffinity {
+            if podMatchesTerm(pod, e.pod, w.PodAffinityTerm) && sameTopology(node, e.node, w.PodAffinityTerm.TopologyKey) {
+                raw -= w.Weight
+            }
+        }
+    }
+    return raw
+}
+
+// normalizeScores 把原始得分线性映射到 [0, maxNodeScore]，得分全部相同时均为 0
+func normalizeScores(scores map[*Node]int64) {
+    first := true
+    var min, max int64
+    for _, score := range scores {
+        if first || score < min {
+            min = score
+        }
+        if first || score > max {
+            max = score
+        }
+        first = false
+    }
+    for node, score := range scores {
+        if max > min {
+            scores[node] = maxNodeScore * (score - min) / (max - min)
+        } else {
+            scores[node] = 0
+        }
+    }
+}
@@ -0,0 +1,141 @@
+package main
+
+import "testing"
+
+var (
+    zoneA1   = labeledNode("a1", map[string]string{"zone": "a", "host": "a1"})
+    zoneA2   = labeledNode("a2", map[string]string{"zone": "a", "host": "a2"})
+    zoneB1   = labeledNode("b1", map[string]string{"zone": "b", "host": "b1"})
+    zoneless = labeledNode("bare", map[string]string{"host": "bare"})
+)
+
+func affinityTerm(app, topologyKey string, namespaces ...string) PodAffinityTerm {
+    return PodAffinityTerm{
+        LabelSelector: &LabelSelector{MatchLabels: map[string]string{"app": app}},
+        Namespaces:    namespaces,
+        TopologyKey:   topologyKey,
+    }
+}
+
+func withAffinity(pod *Pod, affinity, antiAffinity []PodAffinityTerm) *Pod {
+    pod.Spec.Affinity = &Affinity{
+        PodAffinity:     &PodAffinity{RequiredDuringSchedulingIgnoredDuringExecution: affinity},
+        PodAntiAffinity: &PodAntiAffinity{RequiredDuringSchedulingIgnoredDuringExecution: antiAffinity},
+    }
+    return pod
+}
+
+func webPod(namespace, name string) *Pod {
+    return labeledPod(namespace, name, map[string]string{"app": "web"})
+}
+
+func TestFitsInterPodAffinity(t *testing.T) {
+    dbAvoidingWeb := withAffinity(labeledPod("default", "db", map[string]string{"app": "db"}),
+        nil, []PodAffinityTerm{affinityTerm("web", "zone")})
+    otherDBAvoidingWeb := withAffinity(labeledPod("other", "db", map[string]string{"app": "db"}),
+        nil, []PodAffinityTerm{affinityTerm("web", "zone")})
+
+    tests := []struct {
+        name     string
+        pod      *Pod
+        existing []scheduledPod
+        node     *Node
+        fits     bool
+    }{
+        // 第一个 pod 匹配自己的亲和性 term 时可以调度
+        {"first pod matches itself", withAffinity(webPod("default", "web"), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            nil, zoneA1, true},
+        {"first pod missing topology label", withAffinity(webPod("default", "web"), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            nil, zoneless, false},
+        {"first pod does not match itself", withAffinity(labeledPod("default", "api", map[string]string{"app": "api"}), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            nil, zoneA1, false},
+        // 已有匹配的 pod 时不再适用自匹配的例外
+        {"affinity in same zone", withAffinity(webPod("default", "web"), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            []scheduledPod{{webPod("default", "web-0"), zoneA1}}, zoneA2, true},
+        {"affinity in other zone", withAffinity(webPod("default", "web"), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            []scheduledPod{{webPod("default", "web-0"), zoneA1}}, zoneB1, false},
+        {"affinity term namespace defaults to pod namespace", withAffinity(webPod("default", "web"), []PodAffinityTerm{affinityTerm("web", "zone")}, nil),
+            []scheduledPod{{webPod("other", "web-0"), zoneA1}}, zoneA2, true},
+
+        {"anti-affinity in same zone", withAffinity(webPod("default", "web"), nil, []PodAffinityTerm{affinityTerm("web", "zone")}),
+            []scheduledPod{{webPod("default", "web-0"), zoneA1}}, zoneA2, false},
+        {"anti-affinity in other zone", withAffinity(webPod("default", "web"), nil, []PodAffinityTerm{affinityTerm("web", "zone")}),
+            []scheduledPod{{webPod("default", "web-0"), zoneA1}}, zoneB1, true},
+        {"anti-affinity node missing topology label", withAffinity(webPod("default", "web"), nil, []PodAffinityTerm{affinityTerm("web", "zone")}),
+            []scheduledPod{{webPod("default", "web-0"), zoneless}}, zoneless, true},
+        {"anti-affinity ignores other namespace", withAffinity(webPod("default", "web"), nil, []PodAffinityTerm{affinityTerm("web", "zone")}),
+            []scheduledPod{{webPod("other", "web-0"), zoneA1}}, zoneA2, true},
+        {"anti-affinity with explicit namespace", withAffinity(webPod("default", "web"), nil, []PodAffinityTerm{affinityTerm("web", "zone", "other")}),
+            []scheduledPod{{webPod("other", "web-0"), zoneA1}}, zoneA2, false},
+
+        // 已有 pod 的反亲和性对称生效，term 的 namespace 默认取已有 pod 所在的 namespace
+        {"existing anti-affinity blocks", webPod("default", "web"),
+            []scheduledPod{{dbAvoidingWeb, zoneA1}}, zoneA2, false},
+        {"existing anti-affinity other zone", webPod("default", "web"),
+            []scheduledPod{{dbAvoidingWeb, zoneA1}}, zoneB1, true},
+        {"existing anti-affinity other namespace", webPod("default", "web"),
+            []scheduledPod{{otherDBAvoidingWeb, zoneA1}}, zoneA2, true},
+    }
+    for _, tt := range tests {
+        err := fitsInterPodAffinity(tt.pod, tt.node, tt.existing)
+        if (err == nil) != tt.fits {
+            t.Errorf("%s: fitsInterPodAffinity() = %v, want fits %v", tt.name, err, tt.fits)
+        }
+    }
+}
+
+func TestInterPodAffinityScore(t *testing.T) {
+    preferred := func(pod *Pod, affinity, antiAffinity []WeightedPodAffinityTerm) *Pod {
+        pod.Spec.Affinity = &Affinity{
+            PodAffinity:     &PodAffinity{PreferredDuringSchedulingIgnoredDuringExecution: affinity},
+            PodAntiAffinity: &PodAntiAffinity{PreferredDuringSchedulingIgnoredDuringExecution: antiAffinity},
+        }
+        return pod
+    }
+    nodes := []*Node{zoneA1, zoneB1, zoneless}
+
+    tests := []struct {
+        name     string
+        pod      *Pod
+        existing []scheduledPod
+        raw      []int64
+        want     []int64
+    }{
+        {"preferred affinity", preferred(webPod("default", "web"), []WeightedPodAffinityTerm{{Weight: 10, PodAffinityTerm: affinityTerm("web", "zone")}}, nil),
+            []scheduledPod{{webPod("default", "web-0"), zoneA2}}, []int64{10, 0, 0}, []int64{100, 0, 0}},
+        {"preferred anti-affinity", preferred(webPod("default", "web"), nil, []WeightedPodAffinityTerm{{Weight: 5, PodAffinityTerm: affinityTerm("web", "zone")}}),
+            []scheduledPod{{webPod("default", "web-0"), zoneA2}}, []int64{-5, 0, 0}, []int64{0, 100, 100}},
+        // 已有 pod 的 preferred 规则对称计入
+        {"existing preferred affinity", webPod("default", "web"),
+            []scheduledPod{{preferred(labeledPod("default", "cache", nil), []WeightedPodAffinityTerm{{Weight: 4, PodAffinityTerm: affinityTerm("web", "zone")}}, nil), zoneB1}},
+            []int64{0, 4, 0}, []int64{0, 100, 0}},
+        {"all equal", webPod("default", "web"),
+            []scheduledPod{{webPod("default", "web-0"), zoneA1}}, []int64{0, 0, 0}, []int64{0, 0, 0}},
+    }
+    for _, tt := range tests {
+        scores := make(map[*Node]int64)
+        for i, node := range nodes {
+            raw := interPodAffinityRawScore(tt.pod, node, tt.existing)
+            if raw != tt.raw[i] {
+                t.Errorf("%s: raw score on %s = %d, want %d", tt.name, node.Metadata.Name, raw, tt.raw[i])
+            }
+            scores[node] = raw
+        }
+        normalizeScores(scores)
+        for i, node := range nodes {
+            if scores[node] != tt.want[i] {
+                t.Errorf("%s: normalized score on %s = %d, want %d", tt.name, node.Metadata.Name, scores[node], tt.want[i])
+            }
+        }
+    }
+}
+
+func TestNormalizeScoresAllEqual(t *testing.T) {
+    scores := map[*Node]int64{zoneA1: 7, zoneB1: 7}
+    normalizeScores(scores)
+    for node, score := range scores {
+        if score != 0 {
+            t.Errorf("score on %s = %d, want 0 when all raw scores are equal", node.Metadata.Name, score)
+        }
+    }
+}
@@ -14,19 +14,52 @@
 package main
 
 import (
+    "flag"
     "log"
     "os"
     "os/signal"
+    "strings"
     "sync"
     "syscall"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
 )
 
 func main() {
+    kubeconfig := flag.String("kubeconfig", "", "path to a kubeconfig file, defaults to $KUBECONFIG or the in-cluster service account")
+    context := flag.String("context", "", "kubeconfig context to use, defaults to current-context")
+    master := flag.String("master", "", "address of the API server, overrides the kubeconfig server")
+    names := flag.String("scheduler-name", strings.Join(schedulerNames, ","), "comma separated scheduler names this instance is responsible for, ignored when -config is set")
+    config := flag.String("config", "", "path to a scheduler config file defining plugin profiles")
+    flag.Parse()
+
     log.Println("Starting custom scheduler...")
 
+    // 配置文件中的每个 profile 对应一个 schedulerName，否则所有名字共用默认插件
+    var err error
+    if *config != "" {
+        schedulerNames, profiles, err = loadSchedulerConfig(*config)
+    } else {
+        schedulerNames, err = parseSchedulerNames(*names)
+        if err == nil {
+            profiles, err = defaultProfiles(schedulerNames)
+        }
+    }
+    errFatal(err, "failed to create scheduling profiles")
+
+    c, err := kube.NewClient(*kubeconfig, *context, *master)
+    errFatal(err, "failed to create API client")
+    client = c
+    log.Printf("Using API server %s", client.Server())
+    log.Printf("Scheduling pods for scheduler names %v", schedulerNames)
+
     doneChan := make(chan struct{})
     var wg sync.WaitGroup
 
+    wg.Add(1)
+    // 通过 List/Watch 维护节点和 pod 缓存
+    go cache.run(doneChan, &wg)
+
     wg.Add(1)
     // 监测待调度的pod并调度
     go monitorUnscheduledPods(doneChan, &wg)
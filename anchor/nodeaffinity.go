@@ -0,0 +1,148 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+)
+
+const (
+    selectorOpIn           = "In"
+    selectorOpNotIn        = "NotIn"
+    selectorOpExists       = "Exists"
+    selectorOpDoesNotExist = "DoesNotExist"
+    selectorOpGt           = "Gt"
+    selectorOpLt           = "Lt"
+)
+
+// fitsNodeAffinity 检查节点是否满足 pod 的 nodeSelector 及
+// requiredDuringSchedulingIgnoredDuringExecution 节点亲和性，不满足时返回原因
+func fitsNodeAffinity(pod *Pod, node *Node) error {
+    keys := make([]string, 0, len(pod.Spec.NodeSelector))
+    for key := range pod.Spec.NodeSelector {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        value := pod.Spec.NodeSelector[key]
+        if actual, ok := node.Metadata.Labels[key]; !ok || actual != value {
+            return fmt.Errorf("node doesn't match nodeSelector %s=%s", key, value)
+        }
+    }
+
+    affinity := pod.Spec.Affinity
+    if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+        return nil
+    }
+    terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+    if err := matchNodeSelectorTerms(terms, node); err != nil {
+        return fmt.Errorf("node doesn't match required node affinity: %v", err)
+    }
+    return nil
+}
+
+// matchNodeSelectorTerms 任一 term 匹配即可，全部不匹配时返回第一个 term 的失败原因
+func matchNodeSelectorTerms(terms []NodeSelectorTerm, node *Node) error {
+    if len(terms) == 0 {
+        return fmt.Errorf("no node selector terms")
+    }
+
+    var first error
+    for _, term := range terms {
+        err := matchNodeSelectorTerm(term, node)
+        if err == nil {
+            return nil
+        }
+        if first == nil {
+            first = err
+        }
+    }
+    return first
+}
+
+func matchNodeSelectorTerm(term NodeSelectorTerm, node *Node) error {
+    // 空的 term 不匹配任何节点
+    if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+        return fmt.Errorf("empty node selector term")
+    }
+    for _, req := range term.MatchExpressions {
+        value, ok := node.Metadata.Labels[req.Key]
+        matched, err := matchSelectorRequirement(req, value, ok)
+        if err != nil {
+            return err
+        }
+        if !matched {
+            return fmt.Errorf("label %s %s", req.Key, describeRequirement(req))
+        }
+    }
+    // matchFields 只支持 metadata.name
+    for _, req := range term.MatchFields {
+        if req.Key != "metadata.name" {
+            return fmt.Errorf("unsupported field selector %s", req.Key)
+        }
+        if req.Operator != selectorOpIn && req.Operator != selectorOpNotIn {
+            return fmt.Errorf("unsupported operator %s for field %s", req.Operator, req.Key)
+        }
+        matched, err := matchSelectorRequirement(req, node.Metadata.Name, true)
+        if err != nil {
+            return err
+        }
+        if !matched {
+            return fmt.Errorf("field %s %s", req.Key, describeRequirement(req))
+        }
+    }
+    return nil
+}
+
+// matchSelectorRequirement 用 value（exists 表示该 key 是否存在）判断单个条件
+func matchSelectorRequirement(req NodeSelectorRequirement, value string, exists bool) (bool, error) {
+    switch req.Operator {
+    case selectorOpIn:
+        return exists && containsString(req.Values, value), nil
+    case selectorOpNotIn:
+        return !exists || !containsString(req.Values, value), nil
+    case selectorOpExists:
+        return exists, nil
+    case selectorOpDoesNotExist:
+        return !exists, nil
+    case selectorOpGt, selectorOpLt:
+        if len(req.Values) != 1 {
+            return false, fmt.Errorf("operator %s on %s requires exactly one value", req.Operator, req.Key)
+        }
+        bound, err := strconv.ParseInt(req.Values[0], 10, 64)
+        if err != nil {
+            return false, fmt.Errorf("invalid value %q for operator %s on %s", req.Values[0], req.Operator, req.Key)
+        }
+        if !exists {
+            return false, nil
+        }
+        actual, err := strconv.ParseInt(value, 10, 64)
+        if err != nil {
+            return false, nil
+        }
+        if req.Operator == selectorOpGt {
+            return actual > bound, nil
+        }
+        return actual < bound, nil
+    }
+    return false, fmt.Errorf("unknown operator %s on %s", req.Operator, req.Key)
+}
+
+func describeRequirement(req NodeSelectorRequirement) string {
+    switch req.Operator {
+    case selectorOpExists:
+        return "does not exist"
+    case selectorOpDoesNotExist:
+        return "exists"
+    }
+    return fmt.Sprintf("not %s %v", req.Operator, req.Values)
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
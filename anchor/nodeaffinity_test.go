@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestMatchSelectorRequirement(t *testing.T) {
+    labels := map[string]string{"zone": "a", "cpus": "16", "gen": "v2"}
+    tests := []struct {
+        name    string
+        req     NodeSelectorRequirement
+        matched bool
+        wantErr bool
+    }{
+        {"In", NodeSelectorRequirement{Key: "zone", Operator: selectorOpIn, Values: []string{"a", "b"}}, true, false},
+        {"In other value", NodeSelectorRequirement{Key: "zone", Operator: selectorOpIn, Values: []string{"b"}}, false, false},
+        {"In missing label", NodeSelectorRequirement{Key: "rack", Operator: selectorOpIn, Values: []string{""}}, false, false},
+        {"NotIn", NodeSelectorRequirement{Key: "zone", Operator: selectorOpNotIn, Values: []string{"a"}}, false, false},
+        {"NotIn other value", NodeSelectorRequirement{Key: "zone", Operator: selectorOpNotIn, Values: []string{"b"}}, true, false},
+        {"NotIn missing label", NodeSelectorRequirement{Key: "rack", Operator: selectorOpNotIn, Values: []string{"r1"}}, true, false},
+        {"Exists", NodeSelectorRequirement{Key: "zone", Operator: selectorOpExists}, true, false},
+        {"Exists missing label", NodeSelectorRequirement{Key: "rack", Operator: selectorOpExists}, false, false},
+        {"DoesNotExist", NodeSelectorRequirement{Key: "zone", Operator: selectorOpDoesNotExist}, false, false},
+        {"DoesNotExist missing label", NodeSelectorRequirement{Key: "rack", Operator: selectorOpDoesNotExist}, true, false},
+        {"Gt", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpGt, Values: []string{"8"}}, true, false},
+        {"Gt equal", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpGt, Values: []string{"16"}}, false, false},
+        {"Lt", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpLt, Values: []string{"32"}}, true, false},
+        {"Lt smaller", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpLt, Values: []string{"4"}}, false, false},
+        // 标签值不是整数时不匹配，但不是错误
+        {"Gt non-integer label", NodeSelectorRequirement{Key: "gen", Operator: selectorOpGt, Values: []string{"1"}}, false, false},
+        {"Lt non-integer label", NodeSelectorRequirement{Key: "gen", Operator: selectorOpLt, Values: []string{"3"}}, false, false},
+        {"Gt missing label", NodeSelectorRequirement{Key: "rack", Operator: selectorOpGt, Values: []string{"1"}}, false, false},
+        {"Gt non-integer value", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpGt, Values: []string{"x"}}, false, true},
+        {"Lt two values", NodeSelectorRequirement{Key: "cpus", Operator: selectorOpLt, Values: []string{"1", "2"}}, false, true},
+        {"unknown operator", NodeSelectorRequirement{Key: "zone", Operator: "Like", Values: []string{"a"}}, false, true},
+    }
+    for _, tt := range tests {
+        value, exists := labels[tt.req.Key]
+        matched, err := matchSelectorRequirement(tt.req, value, exists)
+        if (err != nil) != tt.wantErr || matched != tt.matched {
+            t.Errorf("%s: matchSelectorRequirement() = %v, %v, want %v, error %v", tt.name, matched, err, tt.matched, tt.wantErr)
+        }
+    }
+}
+
+func TestMatchNodeSelectorTerms(t *testing.T) {
+    node := labeledNode("node-1", map[string]string{"zone": "a"})
+    zoneIn := func(values ...string) NodeSelectorRequirement {
+        return NodeSelectorRequirement{Key: "zone", Operator: selectorOpIn, Values: values}
+    }
+    nameIs := func(operator string, values ...string) NodeSelectorRequirement {
+        return NodeSelectorRequirement{Key: "metadata.name", Operator: operator, Values: values}
+    }
+
+    tests := []struct {
+        name    string
+        terms   []NodeSelectorTerm
+        matched bool
+    }{
+        {"no terms", nil, false},
+        {"empty term", []NodeSelectorTerm{{}}, false},
+        {"empty term or matching term", []NodeSelectorTerm{{}, {MatchExpressions: []NodeSelectorRequirement{zoneIn("a")}}}, true},
+        {"terms are ORed", []NodeSelectorTerm{
+            {MatchExpressions: []NodeSelectorRequirement{zoneIn("b")}},
+            {MatchExpressions: []NodeSelectorRequirement{zoneIn("a")}}}, true},
+        {"requirements are ANDed", []NodeSelectorTerm{{MatchExpressions: []NodeSelectorRequirement{
+            zoneIn("a"), {Key: "rack", Operator: selectorOpExists}}}}, false},
+        {"matchFields name", []NodeSelectorTerm{{MatchFields: []NodeSelectorRequirement{nameIs(selectorOpIn, "node-1")}}}, true},
+        {"matchFields other name", []NodeSelectorTerm{{MatchFields: []NodeSelectorRequirement{nameIs(selectorOpIn, "node-2")}}}, false},
+        {"matchFields NotIn", []NodeSelectorTerm{{MatchFields: []NodeSelectorRequirement{nameIs(selectorOpNotIn, "node-1")}}}, false},
+        {"matchFields and expressions", []NodeSelectorTerm{{
+            MatchExpressions: []NodeSelectorRequirement{zoneIn("a")},
+            MatchFields:      []NodeSelectorRequirement{nameIs(selectorOpIn, "node-1")}}}, true},
+        {"matchFields unsupported operator", []NodeSelectorTerm{{MatchFields: []NodeSelectorRequirement{nameIs(selectorOpExists)}}}, false},
+        {"matchFields unsupported field", []NodeSelectorTerm{{MatchFields: []NodeSelectorRequirement{
+            {Key: "metadata.uid", Operator: selectorOpIn, Values: []string{"x"}}}}}, false},
+    }
+    for _, tt := range tests {
+        err := matchNodeSelectorTerms(tt.terms, node)
+        if (err == nil) != tt.matched {
+            t.Errorf("%s: matchNodeSelectorTerms() = %v, want matched %v", tt.name, err, tt.matched)
+        }
+    }
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "strings"
+)
+
+// 内置插件，包装 predicate 和 priorities 原有的各项检查与打分
+
+func init() {
+    registerPlugin("NodeAffinity", noArgs(nodeAffinityPlugin{}))
+    registerPlugin("NodeConditions", noArgs(nodeConditionsPlugin{}))
+    registerPlugin("TaintToleration", noArgs(taintTolerationPlugin{}))
+    registerPlugin("NodePorts", noArgs(nodePortsPlugin{}))
+    registerPlugin("InterPodAffinity", noArgs(interPodAffinityPlugin{}))
+    registerPlugin("PodTopologySpread", noArgs(podTopologySpreadPlugin{}))
+    registerPlugin("VolumeBinding", noArgs(volumeBindingPlugin{}))
+    registerPlugin("NodeVolumeLimits", noArgs(nodeVolumeLimitsPlugin{}))
+    registerPlugin("NodeResourcesFit", noArgs(nodeResourcesFitPlugin{}))
+    registerPlugin("NodeResourcesBalancedAllocation", noArgs(balancedAllocationPlugin{}))
+    registerPlugin("NodeResourcesLeastAllocated", noArgs(leastAllocatedPlugin{}))
+}
+
+// noArgs 用于不接受参数的插件
+func noArgs(p plugin) pluginFactory {
+    return func(args json.RawMessage) (plugin, error) {
+        if len(args) > 0 && string(args) != "null" {
+            return nil, fmt.Errorf("plugin takes no arguments")
+        }
+        return p, nil
+    }
+}
+
+// 本轮调度中与节点无关的数据只计算一次
+
+func podRequests(pod *Pod, info *nodeInfo) (ResourceUsage, error) {
+    v, err := info.snapshot.cycleState("requested", func() (interface{}, error) {
+        return requestedResource(pod)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return v.(ResourceUsage), nil
+}
+
+func existingPods(info *nodeInfo) []scheduledPod {
+    v, _ := info.snapshot.cycleState("scheduledPods", func() (interface{}, error) {
+        return info.snapshot.scheduledPods(), nil
+    })
+    return v.([]scheduledPod)
+}
+
+func podVolumesFor(pod *Pod, info *nodeInfo) (*podVolumes, error) {
+    v, err := info.snapshot.cycleState("volumes", func() (interface{}, error) {
+        return resolvePodVolumes(pod, info.snapshot)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return v.(*podVolumes), nil
+}
+
+func topologySpreadCountsFor(pod *Pod, info *nodeInfo, whenUnsatisfiable string) ([]TopologySpreadConstraint, spreadCounts) {
+    constraints := topologySpreadConstraints(pod, whenUnsatisfiable)
+    v, _ := info.snapshot.cycleState("topologySpread/"+whenUnsatisfiable, func() (interface{}, error) {
+        return countTopologySpread(pod, constraints, info.snapshot.nodes, existingPods(info)), nil
+    })
+    return constraints, v.(spreadCounts)
+}
+
+// scaleScore 把 [0, MaxPriority] 的打分换算到 [0, maxNodeScore]
+func scaleScore(score float64) int64 {
+    return int64(score * float64(maxNodeScore) / float64(MaxPriority))
+}
+
+func filterStatus(err error) *pluginStatus {
+    if err != nil {
+        return unschedulable(err)
+    }
+    return nil
+}
+
+// 节点标签需满足 nodeSelector 和节点亲和性
+type nodeAffinityPlugin struct{}
+
+func (nodeAffinityPlugin) Name() string { return "NodeAffinity" }
+
+func (nodeAffinityPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    return filterStatus(fitsNodeAffinity(pod, info.node))
+}
+
+// 跳过被 cordon、NotReady 或资源压力过大的节点
+type nodeConditionsPlugin struct{}
+
+func (nodeConditionsPlugin) Name() string { return "NodeConditions" }
+
+func (nodeConditionsPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    return filterStatus(fitsNodeConditions(pod, info.node))
+}
+
+// 节点上 NoSchedule、NoExecute 污点需被 pod 容忍，未容忍的 PreferNoSchedule 污点越少得分越高
+type taintTolerationPlugin struct{}
+
+func (taintTolerationPlugin) Name() string { return "TaintToleration" }
+
+func (taintTolerationPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    return filterStatus(fitsTaints(pod, info.node))
+}
+
+func (taintTolerationPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    return int64(countIntolerablePreferNoSchedule(pod, info.node)), nil
+}
+
+func (taintTolerationPlugin) NormalizeScore(pod *Pod, scores map[*Node]int64) {
+    var max int64
+    for _, count := range scores {
+        if count > max {
+            max = count
+        }
+    }
+    for node, count := range scores {
+        scores[node] = scaleScore(taintTolerationScore(int(count), int(max)))
+    }
+}
+
+// 申请的 hostPort 不能与节点上已有 pod 冲突
+type nodePortsPlugin struct{}
+
+func (nodePortsPlugin) Name() string { return "NodePorts" }
+
+func (nodePortsPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    return filterStatus(fitsHostPorts(pod, info.ports))
+}
+
+// pod 间的亲和性与反亲和性
+type interPodAffinityPlugin struct{}
+
+func (interPodAffinityPlugin) Name() string { return "InterPodAffinity" }
+
+func (interPodAffinityPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    return filterStatus(fitsInterPodAffinity(pod, info.node, existingPods(info)))
+}
+
+func (interPodAffinityPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    return interPodAffinityRawScore(pod, info.node, existingPods(info)), nil
+}
+
+func (interPodAffinityPlugin) NormalizeScore(pod *Pod, scores map[*Node]int64) {
+    normalizeScores(scores)
+}
+
+// 各拓扑域间的 pod 数偏差不能超过 maxSkew，ScheduleAnyway 约束用于打分
+type podTopologySpreadPlugin struct{}
+
+func (podTopologySpreadPlugin) Name() string { return "PodTopologySpread" }
+
+func (podTopologySpreadPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    constraints, counts := topologySpreadCountsFor(pod, info, doNotSchedule)
+    return filterStatus(fitsTopologySpread(pod, info.node, constraints, counts))
+}
+
+func (podTopologySpreadPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    constraints, counts := topologySpreadCountsFor(pod, info, scheduleAnyway)
+    return topologySpreadRawScore(info.node, constraints, counts), nil
+}
+
+func (podTopologySpreadPlugin) NormalizeScore(pod *Pod, scores map[*Node]int64) {
+    constraints := topologySpreadConstraints(pod, scheduleAnyway)
+    if len(constraints) == 0 {
+        return
+    }
+    normalizeTopologySpreadScores(constraints, scores)
+}
+
+// 节点需满足 pod 所用 PV 的节点亲和性，PVC 无法解析时终止调度
+type volumeBindingPlugin struct{}
+
+func (volumeBindingPlugin) Name() string { return "VolumeBinding" }
+
+func (volumeBindingPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    volumes, err := podVolumesFor(pod, info)
+    if err != nil {
+        return pluginError(err)
+    }
+    return filterStatus(fitsVolumeNodeAffinity(volumes, info.node))
+}
+
+// 节点上挂载的卷数不能超过 attachable-volumes-* 上限
+type nodeVolumeLimitsPlugin struct{}
+
+func (nodeVolumeLimitsPlugin) Name() string { return "NodeVolumeLimits" }
+
+func (nodeVolumeLimitsPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    volumes, err := podVolumesFor(pod, info)
+    if err != nil {
+        return pluginError(err)
+    }
+    return filterStatus(fitsVolumeLimits(volumes, info.node, existingPods(info)))
+}
+
+// 节点剩余可分配资源需满足 pod 的请求
+type nodeResourcesFitPlugin struct{}
+
+func (nodeResourcesFitPlugin) Name() string { return "NodeResourcesFit" }
+
+func (nodeResourcesFitPlugin) Filter(pod *Pod, info *nodeInfo) *pluginStatus {
+    requested, err := podRequests(pod, info)
+    if err != nil {
+        return pluginError(err)
+    }
+
+    // allocatable 统计节点可分配资源总量
+    allocatable, err := allocatableResource(info.node, info.snapshot.used)
+    if err != nil {
+        return unschedulable(err)
+    }
+
+    printResourceUsage(allocatable, info.node, "Resource Allocatable")
+    printResourceUsage(info.used, info.node, "Resource Used")
+
+    if insufficient := insufficientResources(requested, allocatable); len(insufficient) > 0 {
+        return unschedulable(fmt.Errorf("Insufficient %s", strings.Join(insufficient, ", Insufficient ")))
+    }
+    return nil
+}
+
+// 各资源使用比例越均衡得分越高
+type balancedAllocationPlugin struct{}
+
+func (balancedAllocationPlugin) Name() string { return "NodeResourcesBalancedAllocation" }
+
+func (balancedAllocationPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    requested, err := podRequests(pod, info)
+    if err != nil {
+        return 0, pluginError(err)
+    }
+    allocatable, err := allocatableResource(info.node, info.snapshot.used)
+    if err != nil {
+        return 0, unschedulable(err)
+    }
+    return scaleScore(balancedResourceScore(requested, allocatable)), nil
+}
+
+// 剩余资源越多得分越高
+type leastAllocatedPlugin struct{}
+
+func (leastAllocatedPlugin) Name() string { return "NodeResourcesLeastAllocated" }
+
+func (leastAllocatedPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    requested, err := podRequests(pod, info)
+    if err != nil {
+        return 0, pluginError(err)
+    }
+    allocatable, err := allocatableResource(info.node, info.snapshot.used)
+    if err != nil {
+        return 0, unschedulable(err)
+    }
+    return scaleScore(leastRequestedScore(requested, allocatable)), nil
+}
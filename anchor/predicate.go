@@ -1,173 +1,141 @@
 package main
 
 import (
+    "errors"
     "fmt"
-    "strconv"
+    "sort"
     "strings"
-    "time"
 )
 
-func parseCpu(resource ResourceList) int64 {
-    if cpu, errs := resource["cpu"]; errs {
-        if strings.HasSuffix(cpu, "m") {
-            milliCores := strings.TrimSuffix(cpu, "m")
-            cores, err := strconv.ParseInt(milliCores, 10, 64)
-            errFatal(err, "Failed to parse CPU")
-            return cores
-        }
-        if c, err := strconv.ParseFloat(cpu, 32); err == nil {
-            errFatal(err, "Failed to parse CPU")
-            return int64(c * 1000)
-        }
+// parseResource 解析单项资源，CPU 以毫核计，其余资源（内存、存储等）以基本单位计
+func parseResource(name, quantity string) (int64, error) {
+    var v int64
+    var err error
+    if name == resourceCPU {
+        v, err = quantityMilliValue(quantity)
+    } else {
+        v, err = quantityValue(quantity)
     }
-    return 0
+    if err != nil {
+        return 0, fmt.Errorf("failed to parse %s: %v", name, err)
+    }
+    return v, nil
 }
 
-func parseMemory(resource ResourceList) int64 {
-    if memory, errs := resource["memory"]; errs {
-        if strings.HasSuffix(memory, "Ki") {
-            mem := strings.TrimSuffix(memory, "Ki")
-            m, err := strconv.ParseInt(mem, 10, 64)
-            errFatal(err, "Failed to parse Memory")
-            return m
+func parseResourceList(resource ResourceList) (ResourceUsage, error) {
+    ru := ResourceUsage{}
+    for name, quantity := range resource {
+        v, err := parseResource(name, quantity)
+        if err != nil {
+            return nil, err
         }
+        ru[name] = v
+    }
+    return ru, nil
+}
+
+// nodeAllocatable 返回节点可供 pod 使用的资源总量。
+// 优先使用 status.allocatable（已扣除 kube-reserved、system-reserved 等预留），
+// 某项资源缺失时才退回到 status.capacity
+func nodeAllocatable(node *Node) (ResourceUsage, error) {
+    allocatable, err := parseResourceList(node.Status.Allocatable)
+    if err != nil {
+        return nil, fmt.Errorf("node %s allocatable: %v", node.Metadata.Name, err)
+    }
+    capacity, err := parseResourceList(node.Status.Capacity)
+    if err != nil {
+        return nil, fmt.Errorf("node %s capacity: %v", node.Metadata.Name, err)
     }
-    if memory, errs := resource["memory"]; errs {
-        if strings.HasSuffix(memory, "Mi") {
-            mem := strings.TrimSuffix(memory, "Mi")
-            m, err := strconv.ParseInt(mem, 10, 64)
-            errFatal(err, "Failed to parse Memory")
-            return m * 1024
+    for name, quantity := range capacity {
+        if _, ok := allocatable[name]; !ok {
+            allocatable[name] = quantity
         }
     }
-    return 0
+    return allocatable, nil
 }
 
-func parsePod(resource ResourceList) int64 {
-    if pods, errs := resource["pods"]; errs {
-        p, err := strconv.ParseInt(pods, 10, 64)
-        errFatal(err, "Failed to parse Pods")
-        return p
+// 统计节点上剩余可分配资源
+func allocatableResource(node *Node, used map[string]ResourceUsage) (ResourceUsage, error) {
+    allocatable, err := nodeAllocatable(node)
+    if err != nil {
+        return nil, err
     }
-    return 0
-}
 
-// 统计节点上可分配资源总量
-func allocatableResource(node *Node, used map[string]*ResourceUsage) ResourceUsage {
-    var tr ResourceUsage
-    tr.CPU = parseCpu(node.Status.Capacity)
-    tr.Memory = parseMemory(node.Status.Capacity)
-    tr.Pod = parsePod(node.Status.Capacity)
-
-    var allocatable ResourceUsage
-    allocatable.CPU = tr.CPU - used[node.Metadata.Name].CPU
-    allocatable.Memory = tr.Memory - used[node.Metadata.Name].Memory
-    allocatable.Pod = tr.Pod - used[node.Metadata.Name].Pod
-    return allocatable
+    allocatable.sub(used[node.Metadata.Name])
+    return allocatable, nil
 }
 
-func requestedResource(pod *Pod) ResourceUsage {
-    // 统计待调度pod所需资源总量
-    var rr ResourceUsage
+// requestedResource 计算 pod 的有效资源请求，与上游规则一致：
+// 每项资源取 max(所有业务容器请求之和, 任一 init 容器的请求)，
+// 再加上 RuntimeClass 声明的 spec.overhead，pod 数计为 1。
+// 待调度 pod 的需求和已调度 pod 的占用都按此计算
+func requestedResource(pod *Pod) (ResourceUsage, error) {
+    rr := ResourceUsage{}
     for _, c := range pod.Spec.Containers {
-        cpus := parseCpu(c.Resources.Requests)
-        memorys := parseMemory(c.Resources.Requests)
+        r, err := parseResourceList(c.Resources.Requests)
+        if err != nil {
+            return nil, fmt.Errorf("pod %s container %s: %v", podKey(pod), c.Name, err)
+        }
+        rr.add(r)
+    }
 
-        rr.CPU += cpus
-        rr.Memory += memorys
-        rr.Pod += 1
+    // init 容器依次运行，只需满足其中最大的一个
+    for _, c := range pod.Spec.InitContainers {
+        r, err := parseResourceList(c.Resources.Requests)
+        if err != nil {
+            return nil, fmt.Errorf("pod %s init container %s: %v", podKey(pod), c.Name, err)
+        }
+        for name, quantity := range r {
+            if quantity > rr[name] {
+                rr[name] = quantity
+            }
+        }
     }
-    return rr
-}
 
-func usedResource(nodeList *NodeList, podList *PodList) map[string]*ResourceUsage {
-    used := make(map[string]*ResourceUsage)
-    for _, node := range nodeList.Items {
-        used[node.Metadata.Name] = &ResourceUsage{}
+    overhead, err := parseResourceList(pod.Spec.Overhead)
+    if err != nil {
+        return nil, fmt.Errorf("pod %s overhead: %v", podKey(pod), err)
     }
+    rr.add(overhead)
 
-    // 统计各个各个节点上pod已用资源总量
-    for _, p := range podList.Items {
-        if p.Spec.NodeName == "" {
-            continue
-        }
-        ru := used[p.Spec.NodeName]
-        for _, c := range p.Spec.Containers {
-            cpu := parseCpu(c.Resources.Requests)
-            memorys := parseMemory(c.Resources.Requests)
+    rr[resourcePods] = 1
+    return rr, nil
+}
 
-            ru.CPU += cpu
-            ru.Memory += memorys
+// insufficientResources 返回节点剩余量不足以满足请求的资源名，按名称排序
+func insufficientResources(requested, allocatable ResourceUsage) []string {
+    var names []string
+    for name, quantity := range requested {
+        if quantity > 0 && allocatable[name] < quantity {
+            names = append(names, name)
         }
-        ru.Pod += 1
     }
-    return used
+    sort.Strings(names)
+    return names
 }
 
-func predicate(pod *Pod) ([]*Node, error) {
-    // 获取所有节点
-    nodeList, err := getNodes()
-    errFatal(err, "failed to get nodes")
-
-    // 获取所有pod
-    podList, err := getPods()
-    errFatal(err, "failed to get pods")
-
-    used := usedResource(nodeList, podList)
-
+// predicate 对快照中的每个节点执行已启用的过滤插件，返回可调度的节点
+func predicate(fwk *framework, pod *Pod, snapshot *clusterSnapshot) ([]*Node, error) {
     var nodes []*Node
     failures := make([]string, 0)
 
-    var requested ResourceUsage
-    var allocatable ResourceUsage
-
-    // 统计待调度pod所需资源总量
-    requested = requestedResource(pod)
-
-    for _, node := range nodeList.Items {
-        // allocatable 统计各个节点可分配资源总量
-        allocatable = allocatableResource(node, used)
-
-        printResourceUsage(allocatable, node, "Resource Allocatable")
-        printResourceUsage(*used[node.Metadata.Name], node, "Resource Used")
-
-        if allocatable.CPU < requested.CPU {
-            m := fmt.Sprintf("fit failure on node (%s): Insufficient CPU", node.Metadata.Name)
-            failures = append(failures, m)
-            continue
-        }
-        if allocatable.Memory < requested.Memory {
-            m := fmt.Sprintf("fit failure on node (%s): Insufficient Memory", node.Metadata.Name)
-            failures = append(failures, m)
+    for _, info := range snapshot.nodeInfos() {
+        status := fwk.runFilterPlugins(pod, info)
+        if status == nil {
+            nodes = append(nodes, info.node)
             continue
         }
-        if allocatable.Pod < requested.Pod {
-            m := fmt.Sprintf("fit failure on node (%s): Insufficient Pod", node.Metadata.Name)
-            failures = append(failures, m)
-            continue
+        if status.code == statusError {
+            postEvent(newPodEvent(pod, "Warning", "FailedScheduling", status.message))
+            return nil, errors.New(status.message)
         }
-        nodes = append(nodes, node)
+        failures = append(failures, fmt.Sprintf("fit failure on node (%s): %s", info.node.Metadata.Name, status.message))
     }
 
     if len(nodes) == 0 {
         // 触发异常，表明该pod无法调度
-        timestamp := time.Now().UTC().Format(time.RFC3339)
-        event := Event{
-            Count:          1,
-            Message:        fmt.Sprintf("pod (%s) failed to fit in any node\n%s", pod.Metadata.Name, strings.Join(failures, "\n")),
-            Metadata:       Metadata{GenerateName: pod.Metadata.Name + "-"},
-            Reason:         "FailedScheduling",
-            LastTimestamp:  timestamp,
-            FirstTimestamp: timestamp,
-            Type:           "Warning",
-            Source:         EventSource{Component: "hightower-scheduler"},
-            InvolvedObject: ObjectReference{
-                Kind:      "Pod",
-                Name:      pod.Metadata.Name,
-                Namespace: "default",
-                Uid:       pod.Metadata.Uid,
-            },
-        }
+        message := fmt.Sprintf("pod (%s) failed to fit in any node\n%s", pod.Metadata.Name, strings.Join(failures, "\n"))
+        event := newPodEvent(pod, "Warning", "FailedScheduling", message)
 
         postEvent(event)
     }
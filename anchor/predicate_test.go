@@ -0,0 +1,156 @@
+package main
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+func TestNodeAllocatable(t *testing.T) {
+    tests := []struct {
+        name string
+        node string
+        want ResourceUsage
+    }{
+        {
+            name: "allocatable",
+            node: `{"metadata": {"name": "n"}, "status": {
+                "capacity": {"cpu": "4", "memory": "8Gi", "pods": "110"},
+                "allocatable": {"cpu": "3800m", "memory": "7Gi", "pods": "100"}}}`,
+            want: ResourceUsage{resourceCPU: 3800, resourceMemory: 7 << 30, resourcePods: 100},
+        },
+        {
+            name: "capacity only",
+            node: `{"metadata": {"name": "n"}, "status": {
+                "capacity": {"cpu": "4", "memory": "8Gi", "pods": "110"}}}`,
+            want: ResourceUsage{resourceCPU: 4000, resourceMemory: 8 << 30, resourcePods: 110},
+        },
+        {
+            // 缺失的资源逐项退回 capacity
+            name: "partial allocatable",
+            node: `{"metadata": {"name": "n"}, "status": {
+                "capacity": {"cpu": "4", "memory": "8Gi", "pods": "110", "attachable-volumes-aws-ebs": "25"},
+                "allocatable": {"memory": "6Gi"}}}`,
+            want: ResourceUsage{resourceCPU: 4000, resourceMemory: 6 << 30, resourcePods: 110, "attachable-volumes-aws-ebs": 25},
+        },
+        {
+            name: "allocatable only",
+            node: `{"metadata": {"name": "n"}, "status": {
+                "allocatable": {"cpu": "2", "pods": "10"}}}`,
+            want: ResourceUsage{resourceCPU: 2000, resourcePods: 10},
+        },
+    }
+    for _, tt := range tests {
+        var node Node
+        if err := json.Unmarshal([]byte(tt.node), &node); err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        got, err := nodeAllocatable(&node)
+        if err != nil {
+            t.Errorf("%s: nodeAllocatable() error: %v", tt.name, err)
+            continue
+        }
+        if len(got) != len(tt.want) {
+            t.Errorf("%s: nodeAllocatable() = %v, want %v", tt.name, got, tt.want)
+            continue
+        }
+        for resource, want := range tt.want {
+            if got[resource] != want {
+                t.Errorf("%s: nodeAllocatable()[%s] = %d, want %d", tt.name, resource, got[resource], want)
+            }
+        }
+    }
+}
+
+func TestNodeAllocatableInvalid(t *testing.T) {
+    var node Node
+    node.Metadata.Name = "n"
+    node.Status.Allocatable = ResourceList{resourceMemory: "1K"}
+    if _, err := nodeAllocatable(&node); err == nil {
+        t.Error("nodeAllocatable() = nil error, want invalid quantity")
+    }
+}
+
+// 剩余资源从 allocatable 而非 capacity 中扣除已用量
+func TestAllocatableResourceUsesAllocatable(t *testing.T) {
+    var node Node
+    if err := json.Unmarshal([]byte(`{"metadata": {"name": "n"}, "status": {
+        "capacity": {"cpu": "4"}, "allocatable": {"cpu": "3"}}}`), &node); err != nil {
+        t.Fatal(err)
+    }
+    free, err := allocatableResource(&node, map[string]ResourceUsage{"n": {resourceCPU: 1000}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if free[resourceCPU] != 2000 {
+        t.Errorf("free cpu = %d, want 2000", free[resourceCPU])
+    }
+}
+
+func TestRequestedResource(t *testing.T) {
+    tests := []struct {
+        name string
+        spec string
+        want ResourceUsage
+    }{
+        {"empty pod", `{}`, ResourceUsage{resourcePods: 1}},
+        {"containers summed", `{"containers": [
+            {"name": "a", "resources": {"requests": {"cpu": "100m", "memory": "64Mi"}}},
+            {"name": "b", "resources": {"requests": {"cpu": "200m", "memory": "64Mi"}}}]}`,
+            ResourceUsage{resourceCPU: 300, resourceMemory: 128 << 20, resourcePods: 1}},
+        // 每项资源分别取 init 容器最大值与业务容器之和中较大者
+        {"init container larger than sum", `{
+            "initContainers": [{"name": "init", "resources": {"requests": {"cpu": "500m", "memory": "32Mi"}}}],
+            "containers": [
+                {"name": "a", "resources": {"requests": {"cpu": "100m", "memory": "64Mi"}}},
+                {"name": "b", "resources": {"requests": {"cpu": "200m", "memory": "64Mi"}}}]}`,
+            ResourceUsage{resourceCPU: 500, resourceMemory: 128 << 20, resourcePods: 1}},
+        {"init containers not summed", `{
+            "initContainers": [
+                {"name": "first", "resources": {"requests": {"cpu": "400m"}}},
+                {"name": "second", "resources": {"requests": {"cpu": "300m", "nvidia.com/gpu": "1"}}}],
+            "containers": [{"name": "a", "resources": {"requests": {"cpu": "100m"}}}]}`,
+            ResourceUsage{resourceCPU: 400, "nvidia.com/gpu": 1, resourcePods: 1}},
+        {"overhead added after init max", `{
+            "initContainers": [{"name": "init", "resources": {"requests": {"cpu": "500m"}}}],
+            "containers": [{"name": "a", "resources": {"requests": {"cpu": "100m", "memory": "64Mi"}}}],
+            "overhead": {"cpu": "250m", "memory": "120Mi"}}`,
+            ResourceUsage{resourceCPU: 750, resourceMemory: 184 << 20, resourcePods: 1}},
+        {"pods counted once", `{"containers": [
+            {"name": "a", "resources": {"requests": {"pods": "3"}}},
+            {"name": "b", "resources": {"requests": {"pods": "2"}}}],
+            "overhead": {"pods": "1"}}`,
+            ResourceUsage{resourcePods: 1}},
+    }
+    for _, tt := range tests {
+        pod := &Pod{}
+        pod.Metadata.Name = "pod"
+        if err := json.Unmarshal([]byte(tt.spec), &pod.Spec); err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        got, err := requestedResource(pod)
+        if err != nil {
+            t.Errorf("%s: requestedResource() error: %v", tt.name, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: requestedResource() = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestRequestedResourceInvalid(t *testing.T) {
+    for _, spec := range []string{
+        `{"containers": [{"name": "a", "resources": {"requests": {"memory": "1K"}}}]}`,
+        `{"initContainers": [{"name": "init", "resources": {"requests": {"cpu": "1.2.3"}}}]}`,
+        `{"overhead": {"cpu": "x"}}`,
+    } {
+        pod := &Pod{}
+        if err := json.Unmarshal([]byte(spec), &pod.Spec); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := requestedResource(pod); err == nil {
+            t.Errorf("requestedResource(%s) = nil error, want invalid quantity", spec)
+        }
+    }
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
+)
+
+// NodePrice 插件按节点价格打分：价格来自节点注解，或按实例类型标签查价格表，
+// 再乘以 pod 占用节点的比例得到放置 pod 的边际成本，成本越低得分越高
+
+const (
+    priceAnnotation             = kube.PriceAnnotation
+    instanceTypeLabel           = "node.kubernetes.io/instance-type"
+    instanceTypeLabelDeprecated = "beta.kubernetes.io/instance-type"
+)
+
+func init() {
+    registerPlugin("NodePrice", newNodePricePlugin)
+}
+
+type nodePricePlugin struct {
+    annotation string
+    // prices 为实例类型到每小时价格的映射，节点没有价格注解时使用
+    prices map[string]float64
+}
+
+func newNodePricePlugin(args json.RawMessage) (plugin, error) {
+    var a struct {
+        Annotation string             `json:"annotation"`
+        Prices     map[string]float64 `json:"prices"`
+    }
+    if err := decodeArgs(args, &a); err != nil {
+        return nil, err
+    }
+    if a.Annotation == "" {
+        a.Annotation = priceAnnotation
+    }
+    for instanceType, price := range a.Prices {
+        if price < 0 {
+            return nil, fmt.Errorf("price of instance type %s must not be negative", instanceType)
+        }
+    }
+    return nodePricePlugin{annotation: a.Annotation, prices: a.Prices}, nil
+}
+
+func (nodePricePlugin) Name() string { return "NodePrice" }
+
+// nodePrice 返回节点每小时价格及其来源，注解优先于价格表
+func (p nodePricePlugin) nodePrice(node *Node) (float64, string, bool) {
+    if v, ok := node.Metadata.Annotations[p.annotation]; ok {
+        price, err := strconv.ParseFloat(v, 64)
+        if err == nil && price >= 0 {
+            return price, "annotation " + p.annotation, true
+        }
+    }
+    for _, label := range []string{instanceTypeLabel, instanceTypeLabelDeprecated} {
+        instanceType, ok := node.Metadata.Labels[label]
+        if !ok {
+            continue
+        }
+        if price, ok := p.prices[instanceType]; ok {
+            return price, "price table for instance type " + instanceType, true
+        }
+    }
+    return 0, "", false
+}
+
+// nodeShare 返回 pod 占用节点的比例，取 cpu、内存和 pod 数中占比最大的一项
+func nodeShare(requested, allocatable ResourceUsage) float64 {
+    var share float64
+    for _, name := range []string{resourceCPU, resourceMemory, resourcePods} {
+        if allocatable[name] <= 0 {
+            continue
+        }
+        if s := float64(requested[name]) / float64(allocatable[name]); s > share {
+            share = s
+        }
+    }
+    return share
+}
+
+// marginalCost 计算把 pod 放到节点上的每小时边际成本
+func (p nodePricePlugin) marginalCost(pod *Pod, info *nodeInfo) (float64, float64, float64, string, *pluginStatus) {
+    requested, err := podRequests(pod, info)
+    if err != nil {
+        return 0, 0, 0, "", pluginError(err)
+    }
+    allocatable, err := nodeAllocatable(info.node)
+    if err != nil {
+        return 0, 0, 0, "", unschedulable(err)
+    }
+    price, source, ok := p.nodePrice(info.node)
+    if !ok {
+        return 0, 0, 0, "", nil
+    }
+    share := nodeShare(requested, allocatable)
+    return price * share, price, share, source, nil
+}
+
+// Score 返回以百万分之一为单位的边际成本，由 NormalizeScore 反转为得分
+func (p nodePricePlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    cost, _, _, _, status := p.marginalCost(pod, info)
+    if status != nil {
+        return 0, status
+    }
+    return int64(cost * 1e6), nil
+}
+
+// NormalizeScore 边际成本最低的节点得 maxNodeScore，没有价格的节点得 0 分
+func (p nodePricePlugin) NormalizeScore(pod *Pod, scores map[*Node]int64) {
+    first := true
+    var min, max int64
+    for node, cost := range scores {
+        if _, _, ok := p.nodePrice(node); !ok {
+            continue
+        }
+        if first || cost < min {
+            min = cost
+        }
+        if first || cost > max {
+            max = cost
+        }
+        first = false
+    }
+    for node, cost := range scores {
+        if _, _, ok := p.nodePrice(node); !ok {
+            scores[node] = 0
+        } else if max > min {
+            scores[node] = maxNodeScore * (max - cost) / (max - min)
+        } else {
+            scores[node] = maxNodeScore
+        }
+    }
+}
+
+// Explain 说明选中节点时使用的价格，写入 Scheduled 事件
+func (p nodePricePlugin) Explain(pod *Pod, info *nodeInfo) string {
+    cost, price, share, source, status := p.marginalCost(pod, info)
+    if status != nil {
+        return ""
+    }
+    if source == "" {
+        return "node has no price"
+    }
+    return fmt.Sprintf("price %.4f/h from %s, marginal cost %.4f/h for %.1f%% of the node", price, source, cost, share*100)
+}
@@ -1,11 +1,13 @@
 package main
 
+import "fmt"
+
 const MaxPriority = 10
 
 func balancedResourceScore(requested, allocatable ResourceUsage) float64 {
-    cFraction := fractionOfCapacity(requested.CPU, allocatable.CPU)
-    mFraction := fractionOfCapacity(requested.Memory, allocatable.Memory)
-    pFraction := fractionOfCapacity(requested.Pod, allocatable.Pod)
+    cFraction := fractionOfCapacity(requested[resourceCPU], allocatable[resourceCPU])
+    mFraction := fractionOfCapacity(requested[resourceMemory], allocatable[resourceMemory])
+    pFraction := fractionOfCapacity(requested[resourcePods], allocatable[resourcePods])
 
 	if cFraction >= 1 || mFraction >= 1 || pFraction >= 1 {
 		return 0
@@ -15,49 +17,44 @@ func balancedResourceScore(requested, allocatable ResourceUsage) float64 {
 }
 
 func leastRequestedScore(requested, allocatable ResourceUsage) float64 {
-    cRatio := getLeastRequestedScore(requested.CPU, allocatable.CPU)
-    mRatio := getLeastRequestedScore(requested.Memory, allocatable.Memory)
-    pRatio := getLeastRequestedScore(requested.Pod, allocatable.Pod)
+    cRatio := getLeastRequestedScore(requested[resourceCPU], allocatable[resourceCPU])
+    mRatio := getLeastRequestedScore(requested[resourceMemory], allocatable[resourceMemory])
+    pRatio := getLeastRequestedScore(requested[resourcePods], allocatable[resourcePods])
 
 	return (cRatio + mRatio + pRatio) / 3
 }
 
-func priorities(pod *Pod, nodes []*Node) (*Node, error) {
+// priorities 只给通过 predicate 的节点打分，与过滤使用同一份快照
+func priorities(fwk *framework, pod *Pod, nodes []*Node, snapshot *clusterSnapshot) (*Node, error) {
 
     var bestNode *Node
-    nodeScore := make(map[*Node]float64)
-
-	// 获取所有节点
-	nodeList, err := getNodes()
-	errFatal(err, "failed to get nodes")
-
-	// 获取所有pod
-	podList, err := getPods()
-	errFatal(err, "failed to get pods")
 
-	requested := requestedResource(pod)
-	used := usedResource(nodeList, podList)
-
-	for _, node := range nodeList.Items {
-		nodeScore[node] = 0
+	infos := make([]*nodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		infos = append(infos, snapshot.nodeInfo(node))
 	}
 
-	for _, node := range nodeList.Items {
-
-        allocatable := allocatableResource(node, used)
-        nodeScore[node] += balancedResourceScore(requested, allocatable)
-        nodeScore[node] += leastRequestedScore(requested, allocatable)
-        nodeScore[node] /= 2
-    }
+	nodeScore, err := fwk.runScorePlugins(pod, infos)
+	if err != nil {
+		return nil, err
+	}
 
 	printNodeScores(nodeScore)
 
+    // 按节点顺序比较，得分相同时结果确定；所有节点都是 0 分时也要选出一个
     var maxScore float64 = 0
-    for node, score := range nodeScore {
-        if score > maxScore {
+    for _, node := range nodes {
+        score, ok := nodeScore[node]
+        if !ok {
+            continue
+        }
+        if bestNode == nil || score > maxScore {
             maxScore = score
             bestNode = node
         }
     }
+    if bestNode == nil {
+        return nil, fmt.Errorf("Unable to schedule pod (%s): no feasible node could be scored", pod.Metadata.Name)
+    }
     return bestNode, nil
 }
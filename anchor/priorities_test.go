@@ -0,0 +1,73 @@
+package main
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+)
+
+// node-empty 空闲但有 pod 未容忍的污点，打分更高也不能被选中
+const emptierNodeTainted = `{"metadata": {"resourceVersion": "1"}, "items": [
+    {"metadata": {"name": "node-busy"}, "status": {"allocatable": {"cpu": "4", "memory": "8Gi", "pods": "110"}}},
+    {"metadata": {"name": "node-empty"}, "spec": {"taints": [{"key": "dedicated", "value": "gpu", "effect": "NoSchedule"}]},
+        "status": {"allocatable": {"cpu": "4", "memory": "8Gi", "pods": "110"}}}
+]}`
+
+const busyPods = `{"metadata": {"resourceVersion": "1"}, "items": [
+    {"metadata": {"name": "existing", "namespace": "default"}, "spec": {"nodeName": "node-busy",
+        "containers": [{"name": "app", "resources": {"requests": {"cpu": "3", "memory": "6Gi"}}}]}, "status": {"phase": "Running"}}
+]}`
+
+func TestInfeasibleNodeIsNeverChosen(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, emptierNodeTainted)
+    f.set(podsEndpoint, busyPods)
+    startCache(t)
+    useDefaultProfile(t)
+
+    pod := pendingPod("default", "web", "100m")
+    fwk := frameworkForPod(pod)
+    snapshot := cache.snapshot()
+
+    nodes, err := predicate(fwk, pod, snapshot)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(nodes) != 1 || nodes[0].Metadata.Name != "node-busy" {
+        t.Fatalf("predicate() = %v, want only node-busy", nodeNames(nodes))
+    }
+
+    // 确认打分本身偏向 node-empty，才能说明过滤结果被遵守
+    all := snapshot.nodeInfos()
+    scores, err := fwk.runScorePlugins(pod, all)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if scores[all[1].node] <= scores[all[0].node] {
+        t.Fatalf("node-empty score %v not above node-busy %v", scores[all[1].node], scores[all[0].node])
+    }
+
+    best, err := priorities(fwk, pod, nodes, snapshot)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if best.Metadata.Name != "node-busy" {
+        t.Errorf("priorities() = %s, want node-busy", best.Metadata.Name)
+    }
+
+    if err := schedulePod(pod); err != nil {
+        t.Fatal(err)
+    }
+    bindings := f.requestsTo(http.MethodPost, "/api/v1/namespaces/default/pods/web/binding/")
+    if len(bindings) != 1 || !strings.Contains(bindings[0].body, `"name":"node-busy"`) {
+        t.Errorf("binding requests = %v, want one to node-busy", bindings)
+    }
+}
+
+func nodeNames(nodes []*Node) []string {
+    var names []string
+    for _, node := range nodes {
+        names = append(names, node.Metadata.Name)
+    }
+    return names
+}
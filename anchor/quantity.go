@@ -0,0 +1,135 @@
+package main
+
+import (
+    "fmt"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+// 按 Kubernetes resource.Quantity 的语法解析资源数量：
+//   <数值><后缀>，数值为可带符号的十进制小数，后缀可以是
+//   二进制 SI（Ki Mi Gi Ti Pi Ei）、十进制 SI（n u m k M G T P E）或指数（e3、E-2）。
+// 计算全程使用有理数，取整规则与上游一致：向上取整。
+
+var binarySuffixes = map[string]uint{
+    "Ki": 10,
+    "Mi": 20,
+    "Gi": 30,
+    "Ti": 40,
+    "Pi": 50,
+    "Ei": 60,
+}
+
+var decimalSuffixes = map[string]int{
+    "n": -9,
+    "u": -6,
+    "m": -3,
+    "":  0,
+    "k": 3,
+    "M": 6,
+    "G": 9,
+    "T": 12,
+    "P": 15,
+    "E": 18,
+}
+
+// 指数的上限，超出后无论如何都无法用 int64 表示
+const maxQuantityExponent = 100
+
+func parseQuantity(s string) (*big.Rat, error) {
+    str := strings.TrimSpace(s)
+    if str == "" {
+        return nil, fmt.Errorf("quantity %q: empty value", s)
+    }
+
+    // 拆分数值与后缀
+    end := 0
+    if str[0] == '+' || str[0] == '-' {
+        end = 1
+    }
+    digits, dots := 0, 0
+    for ; end < len(str); end++ {
+        c := str[end]
+        if c == '.' {
+            dots++
+        } else if c >= '0' && c <= '9' {
+            digits++
+        } else {
+            break
+        }
+    }
+    if digits == 0 || dots > 1 {
+        return nil, fmt.Errorf("quantity %q: invalid number", s)
+    }
+    number, suffix := str[:end], str[end:]
+
+    number = strings.TrimSuffix(strings.TrimPrefix(number, "+"), ".")
+    if strings.HasPrefix(number, ".") || strings.HasPrefix(number, "-.") {
+        number = strings.Replace(number, ".", "0.", 1)
+    }
+    value, ok := new(big.Rat).SetString(number)
+    if !ok {
+        return nil, fmt.Errorf("quantity %q: invalid number", s)
+    }
+
+    if shift, ok := binarySuffixes[suffix]; ok {
+        return value.Mul(value, new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), shift))), nil
+    }
+
+    exponent, ok := decimalSuffixes[suffix]
+    if !ok {
+        if len(suffix) < 2 || (suffix[0] != 'e' && suffix[0] != 'E') {
+            return nil, fmt.Errorf("quantity %q: unknown suffix %q", s, suffix)
+        }
+        e, err := strconv.Atoi(suffix[1:])
+        if err != nil {
+            return nil, fmt.Errorf("quantity %q: invalid exponent %q", s, suffix)
+        }
+        exponent = e
+    }
+    if exponent > maxQuantityExponent || exponent < -maxQuantityExponent {
+        return nil, fmt.Errorf("quantity %q: exponent out of range", s)
+    }
+
+    scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exponent))), nil)
+    if exponent >= 0 {
+        return value.Mul(value, new(big.Rat).SetInt(scale)), nil
+    }
+    return value.Quo(value, new(big.Rat).SetInt(scale)), nil
+}
+
+// quantityValue 返回以基本单位计的整数值（如内存的字节数），向上取整
+func quantityValue(s string) (int64, error) {
+    return scaledQuantity(s, 1)
+}
+
+// quantityMilliValue 返回以千分之一为单位的整数值（如 CPU 的毫核），向上取整
+func quantityMilliValue(s string) (int64, error) {
+    return scaledQuantity(s, 1000)
+}
+
+func scaledQuantity(s string, scale int64) (int64, error) {
+    value, err := parseQuantity(s)
+    if err != nil {
+        return 0, err
+    }
+    value.Mul(value, new(big.Rat).SetInt64(scale))
+
+    // 向上取整
+    q, r := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+    if r.Sign() > 0 {
+        q.Add(q, big.NewInt(1))
+    }
+    if !q.IsInt64() {
+        return 0, fmt.Errorf("quantity %q: value out of range", s)
+    }
+    return q.Int64(), nil
+}
+
+func abs(n int) int {
+    if n < 0 {
+        return -n
+    }
+    return n
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestQuantityValue(t *testing.T) {
+    tests := []struct {
+        in   string
+        want int64
+    }{
+        {"1Gi", 1 << 30},
+        {"512M", 512000000},
+        {"2G", 2000000000},
+        {"1e9", 1000000000},
+        {"0.5Gi", 1 << 29},
+        {"1Ti", 1 << 40},
+        {"1048576", 1048576},
+        {"+.5Ki", 512},
+        {"7Ei", 7 << 60},
+        // 向上取整
+        {"1.5", 2},
+        {"100m", 1},
+        {"1.0000001k", 1001},
+    }
+    for _, tt := range tests {
+        got, err := quantityValue(tt.in)
+        if err != nil {
+            t.Errorf("quantityValue(%q) error: %v", tt.in, err)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("quantityValue(%q) = %d, want %d", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestQuantityMilliValue(t *testing.T) {
+    tests := []struct {
+        in   string
+        want int64
+    }{
+        {"1", 1000},
+        {"250m", 250},
+        {"0.1", 100},
+        {"1500u", 2},
+        {"1n", 1},
+        {"2e-3", 2},
+    }
+    for _, tt := range tests {
+        got, err := quantityMilliValue(tt.in)
+        if err != nil {
+            t.Errorf("quantityMilliValue(%q) error: %v", tt.in, err)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("quantityMilliValue(%q) = %d, want %d", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestQuantityInvalid(t *testing.T) {
+    for _, in := range []string{"", "1K", "1ki", "1.2.3", "1e", "1e+", "Gi", ".", "1 Gi", "8Ei", "1e101", "10E"} {
+        if got, err := quantityValue(in); err == nil {
+            t.Errorf("quantityValue(%q) = %d, want error", in, got)
+        }
+    }
+}
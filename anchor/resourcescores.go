@@ -0,0 +1,184 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+)
+
+// 按资源使用率打分的可选插件：NodeResourcesMostAllocated 把 pod 集中到已经较满的节点，
+// RequestedToCapacityRatio 用自定义的分段线性函数把使用率映射为得分。
+// 在 profile 的 score 列表中替换 NodeResourcesLeastAllocated 即可启用
+
+func init() {
+    registerPlugin("NodeResourcesMostAllocated", newMostAllocatedPlugin)
+    registerPlugin("RequestedToCapacityRatio", newRequestedToCapacityRatioPlugin)
+}
+
+// resourceWeight 是参与打分的资源及其权重
+type resourceWeight struct {
+    Name   string `json:"name"`
+    Weight int64  `json:"weight"`
+}
+
+var defaultResourceWeights = []resourceWeight{
+    {Name: resourceCPU, Weight: 1},
+    {Name: resourceMemory, Weight: 1},
+}
+
+// shapePoint 表示使用率（0-100）对应的得分（0-MaxPriority）
+type shapePoint struct {
+    Utilization int64 `json:"utilization"`
+    Score       int64 `json:"score"`
+}
+
+// decodeArgs 解码插件参数，拒绝未知字段以便发现配置中的拼写错误
+func decodeArgs(args json.RawMessage, v interface{}) error {
+    if len(args) == 0 || string(args) == "null" {
+        return nil
+    }
+    decoder := json.NewDecoder(bytes.NewReader(args))
+    decoder.DisallowUnknownFields()
+    return decoder.Decode(v)
+}
+
+func validateResourceWeights(resources []resourceWeight) ([]resourceWeight, error) {
+    if len(resources) == 0 {
+        return defaultResourceWeights, nil
+    }
+    for _, r := range resources {
+        if r.Name == "" {
+            return nil, fmt.Errorf("resource name must not be empty")
+        }
+        if r.Weight <= 0 {
+            return nil, fmt.Errorf("resource %s: weight must be positive", r.Name)
+        }
+    }
+    return resources, nil
+}
+
+// nodeUtilization 返回放置 pod 后节点上各资源的请求总量以及节点可分配总量
+func nodeUtilization(pod *Pod, info *nodeInfo) (ResourceUsage, ResourceUsage, *pluginStatus) {
+    requested, err := podRequests(pod, info)
+    if err != nil {
+        return nil, nil, pluginError(err)
+    }
+    allocatable, err := nodeAllocatable(info.node)
+    if err != nil {
+        return nil, nil, unschedulable(err)
+    }
+    total := info.used.clone()
+    total.add(requested)
+    return total, allocatable, nil
+}
+
+// weightedResourceScore 按资源权重对各资源得分（0-MaxPriority）求加权平均
+func weightedResourceScore(resources []resourceWeight, score func(name string) float64) int64 {
+    var sum, weights float64
+    for _, r := range resources {
+        sum += score(r.Name) * float64(r.Weight)
+        weights += float64(r.Weight)
+    }
+    if weights == 0 {
+        return 0
+    }
+    return scaleScore(sum / weights)
+}
+
+// 节点资源使用比例越高得分越高，用于把 pod 装箱到尽量少的节点上
+type mostAllocatedPlugin struct {
+    resources []resourceWeight
+}
+
+func newMostAllocatedPlugin(args json.RawMessage) (plugin, error) {
+    var a struct {
+        Resources []resourceWeight `json:"resources"`
+    }
+    if err := decodeArgs(args, &a); err != nil {
+        return nil, err
+    }
+    resources, err := validateResourceWeights(a.Resources)
+    if err != nil {
+        return nil, err
+    }
+    return mostAllocatedPlugin{resources: resources}, nil
+}
+
+func (mostAllocatedPlugin) Name() string { return "NodeResourcesMostAllocated" }
+
+func (p mostAllocatedPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    requested, allocatable, status := nodeUtilization(pod, info)
+    if status != nil {
+        return 0, status
+    }
+    return weightedResourceScore(p.resources, func(name string) float64 {
+        return getMostRequestedScore(requested[name], allocatable[name])
+    }), nil
+}
+
+// 使用率按 shape 描述的分段线性函数映射为得分
+type requestedToCapacityRatioPlugin struct {
+    shape     []shapePoint
+    resources []resourceWeight
+}
+
+func newRequestedToCapacityRatioPlugin(args json.RawMessage) (plugin, error) {
+    var a struct {
+        Shape     []shapePoint     `json:"shape"`
+        Resources []resourceWeight `json:"resources"`
+    }
+    if err := decodeArgs(args, &a); err != nil {
+        return nil, err
+    }
+    if len(a.Shape) == 0 {
+        return nil, fmt.Errorf("shape must have at least one point")
+    }
+    for i, p := range a.Shape {
+        if p.Utilization < 0 || p.Utilization > 100 {
+            return nil, fmt.Errorf("shape utilization %d out of range [0, 100]", p.Utilization)
+        }
+        if p.Score < 0 || p.Score > MaxPriority {
+            return nil, fmt.Errorf("shape score %d out of range [0, %d]", p.Score, MaxPriority)
+        }
+        if i > 0 && p.Utilization <= a.Shape[i-1].Utilization {
+            return nil, fmt.Errorf("shape utilization must be strictly increasing")
+        }
+    }
+    resources, err := validateResourceWeights(a.Resources)
+    if err != nil {
+        return nil, err
+    }
+    return requestedToCapacityRatioPlugin{shape: a.Shape, resources: resources}, nil
+}
+
+func (requestedToCapacityRatioPlugin) Name() string { return "RequestedToCapacityRatio" }
+
+func (p requestedToCapacityRatioPlugin) Score(pod *Pod, info *nodeInfo) (int64, *pluginStatus) {
+    requested, allocatable, status := nodeUtilization(pod, info)
+    if status != nil {
+        return 0, status
+    }
+    return weightedResourceScore(p.resources, func(name string) float64 {
+        // 节点没有该资源或请求超出容量时按满载处理
+        utilization := float64(100)
+        if capacity := allocatable[name]; capacity > 0 && requested[name] <= capacity {
+            utilization = float64(requested[name]) * 100 / float64(capacity)
+        }
+        return shapeScore(p.shape, utilization)
+    }), nil
+}
+
+// shapeScore 在相邻两点间线性插值，超出首尾两点的使用率取端点得分
+func shapeScore(shape []shapePoint, utilization float64) float64 {
+    if utilization <= float64(shape[0].Utilization) {
+        return float64(shape[0].Score)
+    }
+    for i := 1; i < len(shape); i++ {
+        prev, next := shape[i-1], shape[i]
+        if utilization <= float64(next.Utilization) {
+            ratio := (utilization - float64(prev.Utilization)) / float64(next.Utilization-prev.Utilization)
+            return float64(prev.Score) + ratio*float64(next.Score-prev.Score)
+        }
+    }
+    return float64(shape[len(shape)-1].Score)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+    "encoding/json"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestShapeScore(t *testing.T) {
+    rising := []shapePoint{{Utilization: 20, Score: 0}, {Utilization: 80, Score: 10}}
+    peak := []shapePoint{{Utilization: 0, Score: 0}, {Utilization: 50, Score: 10}, {Utilization: 100, Score: 2}}
+    single := []shapePoint{{Utilization: 50, Score: 7}}
+
+    tests := []struct {
+        name        string
+        shape       []shapePoint
+        utilization float64
+        want        float64
+    }{
+        // 超出首尾两点时取端点得分
+        {"below first point", rising, 0, 0},
+        {"first point", rising, 20, 0},
+        {"interpolated", rising, 50, 5},
+        {"interpolated fraction", rising, 35, 2.5},
+        {"last point", rising, 80, 10},
+        {"above last point", rising, 100, 10},
+        {"rising segment", peak, 25, 5},
+        {"peak", peak, 50, 10},
+        {"falling segment", peak, 75, 6},
+        {"single point below", single, 0, 7},
+        {"single point above", single, 100, 7},
+    }
+    for _, tt := range tests {
+        if got := shapeScore(tt.shape, tt.utilization); got != tt.want {
+            t.Errorf("%s: shapeScore(%v) = %v, want %v", tt.name, tt.utilization, got, tt.want)
+        }
+    }
+}
+
+func TestNewRequestedToCapacityRatioPlugin(t *testing.T) {
+    tests := []struct {
+        name string
+        args string
+        err  string
+    }{
+        {"no args", ``, "at least one point"},
+        {"empty shape", `{"shape": []}`, "at least one point"},
+        {"utilization below range", `{"shape": [{"utilization": -1, "score": 0}]}`, "out of range"},
+        {"utilization above range", `{"shape": [{"utilization": 101, "score": 0}]}`, "out of range"},
+        {"score above range", `{"shape": [{"utilization": 0, "score": 11}]}`, "out of range"},
+        {"score below range", `{"shape": [{"utilization": 0, "score": -1}]}`, "out of range"},
+        {"repeated utilization", `{"shape": [{"utilization": 10, "score": 0}, {"utilization": 10, "score": 5}]}`, "strictly increasing"},
+        {"decreasing utilization", `{"shape": [{"utilization": 50, "score": 0}, {"utilization": 10, "score": 5}]}`, "strictly increasing"},
+        {"unknown field", `{"shape": [{"utilisation": 10, "score": 0}]}`, "unknown field"},
+        {"resource weight", `{"shape": [{"utilization": 0, "score": 0}], "resources": [{"name": "cpu", "weight": 0}]}`, "weight must be positive"},
+        {"resource name", `{"shape": [{"utilization": 0, "score": 0}], "resources": [{"weight": 1}]}`, "name must not be empty"},
+    }
+    for _, tt := range tests {
+        _, err := newRequestedToCapacityRatioPlugin(json.RawMessage(tt.args))
+        if err == nil || !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("%s: newRequestedToCapacityRatioPlugin() = %v, want error containing %q", tt.name, err, tt.err)
+        }
+    }
+
+    p, err := newRequestedToCapacityRatioPlugin(json.RawMessage(`{"shape": [{"utilization": 0, "score": 0}, {"utilization": 100, "score": 10}]}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := p.(requestedToCapacityRatioPlugin).resources; !reflect.DeepEqual(got, defaultResourceWeights) {
+        t.Errorf("resources = %v, want the defaults %v", got, defaultResourceWeights)
+    }
+}
+
+func TestRequestedToCapacityRatioScore(t *testing.T) {
+    node := &Node{}
+    node.Metadata.Name = "node-1"
+    node.Status.Allocatable = ResourceList{resourceCPU: "4", resourceMemory: "8Gi"}
+
+    tests := []struct {
+        name string
+        args string
+        cpu  string
+        want int64
+    }{
+        // cpu 50%、memory 25%
+        {"weighted average", `{"shape": [{"utilization": 0, "score": 0}, {"utilization": 100, "score": 10}],
+            "resources": [{"name": "cpu", "weight": 3}, {"name": "memory", "weight": 1}]}`, "1", 43},
+        {"peak at half", `{"shape": [{"utilization": 0, "score": 0}, {"utilization": 50, "score": 10}, {"utilization": 100, "score": 0}],
+            "resources": [{"name": "cpu", "weight": 1}]}`, "1", 100},
+        // 请求超出容量时按满载处理
+        {"over capacity", `{"shape": [{"utilization": 0, "score": 0}, {"utilization": 100, "score": 10}],
+            "resources": [{"name": "cpu", "weight": 1}]}`, "8", 100},
+        {"missing resource", `{"shape": [{"utilization": 0, "score": 0}, {"utilization": 100, "score": 10}],
+            "resources": [{"name": "nvidia.com/gpu", "weight": 1}]}`, "1", 100},
+    }
+    for _, tt := range tests {
+        p, err := newRequestedToCapacityRatioPlugin(json.RawMessage(tt.args))
+        if err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        snapshot := &clusterSnapshot{
+            nodes: []*Node{node},
+            used:  map[string]ResourceUsage{"node-1": {resourceCPU: 1000}},
+        }
+        pod := &Pod{}
+        pod.Spec.Containers = []Container{{Name: "app", Resources: ResourceRequirements{
+            Requests: ResourceList{resourceCPU: tt.cpu, resourceMemory: "2Gi"}}}}
+
+        score, status := p.(scorePlugin).Score(pod, snapshot.nodeInfo(node))
+        if status != nil {
+            t.Fatalf("%s: Score() status %v", tt.name, status.message)
+        }
+        if score != tt.want {
+            t.Errorf("%s: Score() = %d, want %d", tt.name, score, tt.want)
+        }
+    }
+}
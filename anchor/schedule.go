@@ -18,19 +18,54 @@ import (
     "log"
     "sync"
     "time"
-    "bytes"
-    "io/ioutil"
     "encoding/json"
-    "net/http"
     "net/url"
     "errors"
+    "strings"
 )
 
 var processorLock = &sync.Mutex{}
-const schedulerName = "hightower"
+const schedulerAnnotation = "scheduler.alpha.kubernetes.io/name"
+
+// 本实例负责调度的 schedulerName 列表，可通过 -scheduler-name 参数修改
+var schedulerNames = []string{"hightower"}
+
+// parseSchedulerNames 解析逗号分隔的名字，去掉空白和空项。
+// 空名字会认领所有未指定 schedulerName 的 pod，因此不允许为空
+func parseSchedulerNames(s string) ([]string, error) {
+    var names []string
+    for _, name := range strings.Split(s, ",") {
+        if name = strings.TrimSpace(name); name != "" {
+            names = append(names, name)
+        }
+    }
+    if len(names) == 0 {
+        return nil, errors.New("at least one scheduler name is required")
+    }
+    return names, nil
+}
+
+// 判断 pod 是否应由本调度器处理，同时兼容 spec.schedulerName 和旧版注解
+func responsibleForPod(pod *Pod) bool {
+    return frameworkForPod(pod) != nil
+}
+
+// frameworkForPod 返回 pod 所属 profile 的调度框架，spec.schedulerName 优先于注解
+func frameworkForPod(pod *Pod) *framework {
+    for _, name := range []string{pod.Spec.SchedulerName, pod.Metadata.Annotations[schedulerAnnotation]} {
+        if fwk, ok := profiles[name]; ok {
+            return fwk
+        }
+    }
+    return nil
+}
 
 // 再次调度，调度多个未调度的pod
 func reconcileUnscheduledPods(interval int, done chan struct{}, wg *sync.WaitGroup) {
+    if !cache.waitForSync(done) {
+        wg.Done()
+        return
+    }
     for {
         select {
         case <-time.After(time.Duration(interval) * time.Second):
@@ -45,7 +80,12 @@ func reconcileUnscheduledPods(interval int, done chan struct{}, wg *sync.WaitGro
 }
 
 func monitorUnscheduledPods(done chan struct{}, wg *sync.WaitGroup) {
-    pods, errc := watchUnscheduledPods()
+    // 等待缓存完成首次同步，否则会基于空的集群状态调度
+    if !cache.waitForSync(done) {
+        wg.Done()
+        return
+    }
+    pods, errc := watchUnscheduledPods(done)
 
     for {
         select {
@@ -53,7 +93,6 @@ func monitorUnscheduledPods(done chan struct{}, wg *sync.WaitGroup) {
             log.Println(err)
         case pod := <-pods:
             processorLock.Lock()
-            time.Sleep(2 * time.Second)
             err := schedulePod(&pod)
             errPrintln(err, "pod schedule failed")
             processorLock.Unlock()
@@ -66,7 +105,20 @@ func monitorUnscheduledPods(done chan struct{}, wg *sync.WaitGroup) {
 }
 
 func schedulePod(pod *Pod) error {
-    nodes, err := predicate(pod)
+    // 已发出 bind 的 pod 可能在 watch 确认前再次出现
+    if cache.isAssumed(pod) {
+        return nil
+    }
+
+    fwk := frameworkForPod(pod)
+    if fwk == nil {
+        return fmt.Errorf("no scheduling profile for pod (%s)", pod.Metadata.Name)
+    }
+
+    // 过滤与打分基于同一份集群快照
+    snapshot := cache.snapshot()
+
+    nodes, err := predicate(fwk, pod, snapshot)
     if err != nil {
         return err
     }
@@ -76,68 +128,70 @@ func schedulePod(pod *Pod) error {
     }
 
     // 选出price最小的节点
-    node, err := priorities(pod, nodes)
+    node, err := priorities(fwk, pod, nodes, snapshot)
     if err != nil {
         return err
     }
-    // 调度节点
-    err = bind(pod, node)
+    reasons := fwk.explain(pod, snapshot.nodeInfo(node))
+
+    // 先假定 pod 已调度到该节点，bind 失败时撤销
+    cache.assumePod(pod, node.Metadata.Name)
+    err = bind(pod, node, reasons)
     if err != nil {
+        cache.forgetPod(pod)
         return err
     }
     return nil
 }
 
-func watchUnscheduledPods() (<-chan Pod, <-chan error) {
+// 监听未绑定节点的 pod，首次 List 以及每次重新 List 时已存在的 pod 也会被送出
+func watchUnscheduledPods(done chan struct{}) (<-chan Pod, <-chan error) {
     pods := make(chan Pod)
     errc := make(chan error, 1)
 
     v := url.Values{}
     v.Set("fieldSelector", "spec.nodeName=")
 
-    request := &http.Request{
-        Header: make(http.Header),
-        Method: http.MethodGet,
-        URL: &url.URL{
-            Host:     apiHost,
-            Path:     watchPodsEndpoint,
-            RawQuery: v.Encode(),
-            Scheme:   "http",
-        },
+    emit := func(pod Pod) {
+        if !responsibleForPod(&pod) {
+            return
+        }
+        select {
+        case pods <- pod:
+        case <-done:
+        }
     }
-    request.Header.Set("Accept", "application/json, */*")
 
-    go func() {
-        for {
-            resp, err := http.DefaultClient.Do(request)
-            // 出错重传
+    lw := &listWatcher{
+        name:  "unscheduled pods",
+        path:  podsEndpoint,
+        query: v,
+        list: func() (string, error) {
+            var podList PodList
+            err := client.Get(podsEndpoint, v, &podList)
             if err != nil {
-                errc <- err
-                time.Sleep(5 * time.Second)
-                continue
+                return "", err
             }
-
-            if resp.StatusCode != 200 {
-                errc <- errors.New("Invalid status code: " + resp.Status)
-                time.Sleep(5 * time.Second)
-                continue
+            for _, pod := range podList.Items {
+                emit(pod)
             }
-
-            decoder := json.NewDecoder(resp.Body)
-            for {
-                var event PodWatchEvent
-                err = decoder.Decode(&event)
-                if err != nil {
-                    errc <- err
-                    break
-                }
-
-                if event.Type == "ADDED" {
-                    pods <- event.Object
-                }
+            return podList.Metadata.ResourceVersion, nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            // 只有新出现的 pod 需要调度，MODIFIED/DELETED 仅用于推进 resourceVersion
+            if eventType != "ADDED" {
+                return nil
             }
-        }
-    }()
+            var pod Pod
+            err := json.Unmarshal(object, &pod)
+            if err != nil {
+                return err
+            }
+            emit(pod)
+            return nil
+        },
+    }
+    go lw.run(done, errc)
 
     return pods, errc
 }
@@ -151,30 +205,14 @@ func getUnscheduledPods() ([]*Pod, error) {
     v := url.Values{}
     v.Set("fieldSelector", "spec.nodeName=")
 
-    request := &http.Request{
-        Header: make(http.Header),
-        Method: http.MethodGet,
-        URL: &url.URL{
-            Host:     apiHost,
-            Path:     podsEndpoint,
-            RawQuery: v.Encode(),
-            Scheme:   "http",
-        },
-    }
-    request.Header.Set("Accept", "application/json, */*")
-
-    resp, err := http.DefaultClient.Do(request)
-    if err != nil {
-        return unscheduledPods, err
-    }
-    err = json.NewDecoder(resp.Body).Decode(&podList)
+    err := client.Get(podsEndpoint, v, &podList)
     if err != nil {
         return unscheduledPods, err
     }
 
-    for _, pod := range podList.Items {
-        if pod.Metadata.Annotations["scheduler.alpha.kubernetes.io/name"] == schedulerName {
-            unscheduledPods = append(unscheduledPods, &pod)
+    for i := range podList.Items {
+        if responsibleForPod(&podList.Items[i]) {
+            unscheduledPods = append(unscheduledPods, &podList.Items[i])
         }
     }
 
@@ -196,12 +234,13 @@ func schedulePods() error {
     return nil
 }
 
-// 调度pod到节点上
-func bind(pod *Pod, node *Node) error {
+// 调度pod到节点上，reasons 附加在 Scheduled 事件中说明选择该节点的理由。
+// 只有 Binding 请求失败才返回错误；此时 pod 已绑定，事件发送失败只记录日志
+func bind(pod *Pod, node *Node, reasons []string) error {
     binding := Binding{
         ApiVersion: "v1",
         Kind:       "Binding",
-        Metadata:   Metadata{Name: pod.Metadata.Name},
+        Metadata:   Metadata{Name: pod.Metadata.Name, Namespace: podNamespace(pod)},
         Target: Target{
             ApiVersion: "v1",
             Kind:       "Node",
@@ -209,54 +248,19 @@ func bind(pod *Pod, node *Node) error {
         },
     }
 
-    var b []byte
-    body := bytes.NewBuffer(b)
-    err := json.NewEncoder(body).Encode(binding)
+    err := client.Post(fmt.Sprintf(bindingsEndpoint, podNamespace(pod), pod.Metadata.Name), binding)
     if err != nil {
-        return err
-    }
-
-    request := &http.Request{
-        Body:          ioutil.NopCloser(body),
-        ContentLength: int64(body.Len()),
-        Header:        make(http.Header),
-        Method:        http.MethodPost,
-        URL: &url.URL{
-            Host:   apiHost,
-            Path:   fmt.Sprintf(bindingsEndpoint, pod.Metadata.Name),
-            Scheme: "http",
-        },
-    }
-    request.Header.Set("Content-Type", "application/json")
-
-    resp, err := http.DefaultClient.Do(request)
-    if err != nil {
-        return err
-    }
-    if resp.StatusCode != 201 {
-        return errors.New("Binding: Unexpected HTTP status code" + resp.Status)
+        return errors.New("Binding: " + err.Error())
     }
 
     // Emit a Kubernetes event that the Pod was scheduled successfully.
     message := fmt.Sprintf("Successfully assigned %s to %s", pod.Metadata.Name, node.Metadata.Name)
-    timestamp := time.Now().UTC().Format(time.RFC3339)
-    event := Event{
-        Count:          1,
-        Message:        message,
-        Metadata:       Metadata{GenerateName: pod.Metadata.Name + "-"},
-        Reason:         "Scheduled",
-        LastTimestamp:  timestamp,
-        FirstTimestamp: timestamp,
-        Type:           "Normal",
-        Source:         EventSource{Component: "hightower-scheduler"},
-        InvolvedObject: ObjectReference{
-            Kind:      "Pod",
-            Name:      pod.Metadata.Name,
-            Namespace: "default",
-            Uid:       pod.Metadata.Uid,
-        },
+    if len(reasons) > 0 {
+        message += " (" + strings.Join(reasons, "; ") + ")"
     }
+    event := newPodEvent(pod, "Normal", "Scheduled", message)
     log.Println(message)
-    return postEvent(event)
+    errPrintln(postEvent(event), "failed to post Scheduled event")
+    return nil
 }
 
@@ -0,0 +1,43 @@
+package main
+
+import (
+    "net/http"
+    "testing"
+)
+
+const twoPendingPods = `{"metadata": {"resourceVersion": "1"}, "items": [
+    {"metadata": {"name": "a", "namespace": "default"}, "spec": {"schedulerName": "hightower",
+        "containers": [{"name": "app", "resources": {"requests": {"cpu": "100m"}}}]}, "status": {"phase": "Pending"}},
+    {"metadata": {"name": "b", "namespace": "default"}, "spec": {"schedulerName": "hightower",
+        "containers": [{"name": "app", "resources": {"requests": {"cpu": "100m"}}}]}, "status": {"phase": "Pending"}}
+]}`
+
+// 定期补偿调度要处理列表中的每个 pod，而不是重复最后一个
+func TestSchedulePodsBindsEveryPendingPod(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, oneNode)
+    f.set(podsEndpoint, twoPendingPods)
+    startCache(t)
+    useDefaultProfile(t)
+
+    pods, err := getUnscheduledPods()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(pods) != 2 || pods[0].Metadata.Name != "a" || pods[1].Metadata.Name != "b" {
+        var names []string
+        for _, pod := range pods {
+            names = append(names, pod.Metadata.Name)
+        }
+        t.Fatalf("getUnscheduledPods() = %v, want [a b]", names)
+    }
+
+    if err := schedulePods(); err != nil {
+        t.Fatal(err)
+    }
+    for _, name := range []string{"a", "b"} {
+        if n := len(f.requestsTo(http.MethodPost, "/api/v1/namespaces/default/pods/"+name+"/binding/")); n != 1 {
+            t.Errorf("pod %s got %d binding requests, want 1", name, n)
+        }
+    }
+}
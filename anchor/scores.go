@@ -23,3 +23,11 @@ func getLeastRequestedScore(requested, capacity int64) float64 {
     }
     return float64(capacity - requested) * float64(MaxPriority) / float64(capacity)
 }
+
+// getMostRequestedScore 与 getLeastRequestedScore 相反，节点资源使用比例越高得分越高
+func getMostRequestedScore(requested, capacity int64) float64 {
+    if capacity == 0 || requested > capacity {
+        return 0
+    }
+    return float64(requested) * float64(MaxPriority) / float64(capacity)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// toleratesTaint 按上游规则判断单个容忍是否匹配污点：
+// effect 为空匹配所有 effect；Exists 且 key 为空匹配所有污点；Equal（默认）要求 value 相等
+func toleratesTaint(toleration Toleration, taint Taint) bool {
+    if toleration.Effect != "" && toleration.Effect != taint.Effect {
+        return false
+    }
+    if toleration.Key != "" && toleration.Key != taint.Key {
+        return false
+    }
+    switch toleration.Operator {
+    case tolerationOpExists:
+        return true
+    case "", tolerationOpEqual:
+        return toleration.Key != "" && toleration.Value == taint.Value
+    }
+    return false
+}
+
+func toleratesAny(tolerations []Toleration, taint Taint) bool {
+    for _, toleration := range tolerations {
+        if toleratesTaint(toleration, taint) {
+            return true
+        }
+    }
+    return false
+}
+
+// fitsTaints 检查 pod 是否容忍节点上所有 NoSchedule 和 NoExecute 污点
+func fitsTaints(pod *Pod, node *Node) error {
+    for _, taint := range node.Spec.Taints {
+        if taint.Effect != taintEffectNoSchedule && taint.Effect != taintEffectNoExecute {
+            continue
+        }
+        if !toleratesAny(pod.Spec.Tolerations, taint) {
+            return fmt.Errorf("node had untolerated taint {%s: %s}", taint.Key, taint.Value)
+        }
+    }
+    return nil
+}
+
+// countIntolerablePreferNoSchedule 统计 pod 未容忍的 PreferNoSchedule 污点数，
+// 只有 effect 为空或 PreferNoSchedule 的容忍参与匹配
+func countIntolerablePreferNoSchedule(pod *Pod, node *Node) int {
+    var tolerations []Toleration
+    for _, toleration := range pod.Spec.Tolerations {
+        if toleration.Effect == "" || toleration.Effect == taintEffectPreferNoSchedule {
+            tolerations = append(tolerations, toleration)
+        }
+    }
+
+    count := 0
+    for _, taint := range node.Spec.Taints {
+        if taint.Effect == taintEffectPreferNoSchedule && !toleratesAny(tolerations, taint) {
+            count++
+        }
+    }
+    return count
+}
+
+// taintTolerationScore 未容忍的 PreferNoSchedule 污点越少得分越高
+func taintTolerationScore(count, maxCount int) float64 {
+    if maxCount == 0 {
+        return float64(MaxPriority)
+    }
+    return float64(MaxPriority) * float64(maxCount-count) / float64(maxCount)
+}
+
+// 节点状态对应的内置污点，与 node lifecycle controller 按状态添加的 NoSchedule 污点一致，
+// pod 容忍这些污点时即使节点处于相应状态也可以调度。
+// DefaultTolerationSeconds 给每个 pod 加上的 not-ready、unreachable NoExecute 容忍只推迟驱逐，不能放行调度
+var (
+    taintUnschedulable = Taint{Key: "node.kubernetes.io/unschedulable", Effect: taintEffectNoSchedule}
+    taintNotReady      = Taint{Key: "node.kubernetes.io/not-ready", Effect: taintEffectNoSchedule}
+    taintUnreachable   = Taint{Key: "node.kubernetes.io/unreachable", Effect: taintEffectNoSchedule}
+    conditionTaints    = map[string]Taint{
+        "MemoryPressure": {Key: "node.kubernetes.io/memory-pressure", Effect: taintEffectNoSchedule},
+        "DiskPressure":   {Key: "node.kubernetes.io/disk-pressure", Effect: taintEffectNoSchedule},
+        "PIDPressure":    {Key: "node.kubernetes.io/pid-pressure", Effect: taintEffectNoSchedule},
+    }
+)
+
+// fitsNodeConditions 过滤被 cordon、NotReady 以及存在内存、磁盘、PID 压力的节点，
+// 返回的错误中列出所有未被容忍的原因
+func fitsNodeConditions(pod *Pod, node *Node) error {
+    var reasons []string
+    if node.Spec.Unschedulable && !toleratesAny(pod.Spec.Tolerations, taintUnschedulable) {
+        reasons = append(reasons, "node is unschedulable")
+    }
+
+    for _, condition := range node.Status.Conditions {
+        if condition.Type == "Ready" {
+            switch condition.Status {
+            case "False":
+                if !toleratesAny(pod.Spec.Tolerations, taintNotReady) {
+                    reasons = append(reasons, "node is not ready")
+                }
+            case "Unknown":
+                if !toleratesAny(pod.Spec.Tolerations, taintUnreachable) {
+                    reasons = append(reasons, "node is unreachable")
+                }
+            }
+            continue
+        }
+
+        taint, ok := conditionTaints[condition.Type]
+        if ok && condition.Status == "True" && !toleratesAny(pod.Spec.Tolerations, taint) {
+            reasons = append(reasons, "node has "+condition.Type)
+        }
+    }
+
+    if len(reasons) > 0 {
+        return errors.New(strings.Join(reasons, ", "))
+    }
+    return nil
+}
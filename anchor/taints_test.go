@@ -0,0 +1,135 @@
+package main
+
+import "testing"
+
+func TestFitsNodeConditionsIgnoresDefaultNoExecuteTolerations(t *testing.T) {
+    seconds := int64(300)
+    // DefaultTolerationSeconds 给每个 pod 添加的容忍
+    defaults := []Toleration{
+        {Key: taintNotReady.Key, Operator: tolerationOpExists, Effect: taintEffectNoExecute, TolerationSeconds: &seconds},
+        {Key: taintUnreachable.Key, Operator: tolerationOpExists, Effect: taintEffectNoExecute, TolerationSeconds: &seconds},
+    }
+
+    tests := []struct {
+        name        string
+        status      string
+        tolerations []Toleration
+        fits        bool
+    }{
+        {"ready", "True", defaults, true},
+        {"not ready with default tolerations", "False", defaults, false},
+        {"unknown with default tolerations", "Unknown", defaults, false},
+        {"not ready tolerated", "False", []Toleration{{Key: taintNotReady.Key, Operator: tolerationOpExists, Effect: taintEffectNoSchedule}}, true},
+        {"unknown tolerated", "Unknown", []Toleration{{Key: taintUnreachable.Key, Operator: tolerationOpExists}}, true},
+    }
+    for _, tt := range tests {
+        node := &Node{}
+        node.Metadata.Name = "node"
+        node.Status.Conditions = []NodeCondition{{Type: "Ready", Status: tt.status}}
+        pod := &Pod{}
+        pod.Spec.Tolerations = tt.tolerations
+
+        err := fitsNodeConditions(pod, node)
+        if (err == nil) != tt.fits {
+            t.Errorf("%s: fitsNodeConditions() = %v, want fits %v", tt.name, err, tt.fits)
+        }
+    }
+}
+
+func TestToleratesTaint(t *testing.T) {
+    taint := Taint{Key: "dedicated", Value: "gpu", Effect: taintEffectNoSchedule}
+    tests := []struct {
+        name       string
+        toleration Toleration
+        tolerates  bool
+    }{
+        {"Equal", Toleration{Key: "dedicated", Operator: tolerationOpEqual, Value: "gpu", Effect: taintEffectNoSchedule}, true},
+        {"Equal other value", Toleration{Key: "dedicated", Operator: tolerationOpEqual, Value: "cpu", Effect: taintEffectNoSchedule}, false},
+        {"default operator is Equal", Toleration{Key: "dedicated", Value: "gpu"}, true},
+        {"default operator other value", Toleration{Key: "dedicated", Value: "cpu"}, false},
+        {"default operator empty key", Toleration{Value: "gpu"}, false},
+        {"Exists", Toleration{Key: "dedicated", Operator: tolerationOpExists}, true},
+        {"Exists other key", Toleration{Key: "gpu", Operator: tolerationOpExists}, false},
+        // key 为空的 Exists 匹配所有污点
+        {"Exists empty key", Toleration{Operator: tolerationOpExists}, true},
+        {"Exists empty key other effect", Toleration{Operator: tolerationOpExists, Effect: taintEffectNoExecute}, false},
+        // effect 为空匹配所有 effect
+        {"empty effect", Toleration{Key: "dedicated", Operator: tolerationOpEqual, Value: "gpu"}, true},
+        {"other effect", Toleration{Key: "dedicated", Operator: tolerationOpEqual, Value: "gpu", Effect: taintEffectPreferNoSchedule}, false},
+        {"unknown operator", Toleration{Key: "dedicated", Operator: "Like", Value: "gpu"}, false},
+    }
+    for _, tt := range tests {
+        if got := toleratesTaint(tt.toleration, taint); got != tt.tolerates {
+            t.Errorf("%s: toleratesTaint() = %v, want %v", tt.name, got, tt.tolerates)
+        }
+    }
+}
+
+func TestFitsTaints(t *testing.T) {
+    node := &Node{}
+    node.Spec.Taints = []Taint{
+        {Key: "dedicated", Value: "gpu", Effect: taintEffectNoSchedule},
+        {Key: "maintenance", Effect: taintEffectNoExecute},
+        {Key: "spot", Effect: taintEffectPreferNoSchedule},
+    }
+    tests := []struct {
+        name        string
+        tolerations []Toleration
+        fits        bool
+    }{
+        {"no tolerations", nil, false},
+        {"NoSchedule only", []Toleration{{Key: "dedicated", Value: "gpu"}}, false},
+        // PreferNoSchedule 污点只影响打分
+        {"NoSchedule and NoExecute", []Toleration{{Key: "dedicated", Value: "gpu"}, {Key: "maintenance", Operator: tolerationOpExists}}, true},
+        {"tolerate everything", []Toleration{{Operator: tolerationOpExists}}, true},
+    }
+    for _, tt := range tests {
+        pod := &Pod{}
+        pod.Spec.Tolerations = tt.tolerations
+        if err := fitsTaints(pod, node); (err == nil) != tt.fits {
+            t.Errorf("%s: fitsTaints() = %v, want fits %v", tt.name, err, tt.fits)
+        }
+    }
+}
+
+func TestCountIntolerablePreferNoSchedule(t *testing.T) {
+    node := &Node{}
+    node.Spec.Taints = []Taint{
+        {Key: "spot", Value: "true", Effect: taintEffectPreferNoSchedule},
+        {Key: "old", Effect: taintEffectPreferNoSchedule},
+        {Key: "dedicated", Value: "gpu", Effect: taintEffectNoSchedule},
+    }
+    tests := []struct {
+        name        string
+        tolerations []Toleration
+        count       int
+    }{
+        {"no tolerations", nil, 2},
+        {"one tolerated", []Toleration{{Key: "spot", Value: "true", Effect: taintEffectPreferNoSchedule}}, 1},
+        {"empty effect", []Toleration{{Key: "old", Operator: tolerationOpExists}}, 1},
+        {"Exists empty key", []Toleration{{Operator: tolerationOpExists}}, 0},
+        // 只对其他 effect 生效的容忍不参与匹配
+        {"NoSchedule toleration ignored", []Toleration{{Operator: tolerationOpExists, Effect: taintEffectNoSchedule}}, 2},
+    }
+    for _, tt := range tests {
+        pod := &Pod{}
+        pod.Spec.Tolerations = tt.tolerations
+        if got := countIntolerablePreferNoSchedule(pod, node); got != tt.count {
+            t.Errorf("%s: countIntolerablePreferNoSchedule() = %d, want %d", tt.name, got, tt.count)
+        }
+    }
+
+    for _, tt := range []struct {
+        count, maxCount int
+        want            float64
+    }{
+        {0, 0, float64(MaxPriority)},
+        {0, 2, float64(MaxPriority)},
+        {1, 2, float64(MaxPriority) / 2},
+        {2, 2, 0},
+    } {
+        if got := taintTolerationScore(tt.count, tt.maxCount); got != tt.want {
+            t.Errorf("taintTolerationScore(%d, %d) = %v, want %v", tt.count, tt.maxCount, got, tt.want)
+        }
+    }
+}
@@ -1,110 +1,118 @@
 package main
 
 import (
-    "log"
-    "encoding/json"
-    "net/http"
-    "bytes"
     "errors"
+    "fmt"
+    "log"
     "net/url"
-    "io/ioutil"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
 )
 
+// 默认沿用 kubectl proxy 的地址，main 中会根据参数替换
+var client = kube.DefaultClient()
+
 var (
-    apiHost           = "127.0.0.1:8080"
-    bindingsEndpoint  = "/api/v1/namespaces/default/pods/%s/binding/"
-    eventsEndpoint    = "/api/v1/namespaces/default/events"
-    nodesEndpoint     = "/api/v1/nodes"
-    podsEndpoint      = "/api/v1/pods"
-    watchPodsEndpoint = "/api/v1/watch/pods"
+    bindingsEndpoint = "/api/v1/namespaces/%s/pods/%s/binding/"
+    eventsEndpoint   = "/api/v1/namespaces/%s/events"
+    nodesEndpoint    = kube.NodesEndpoint
+    podsEndpoint     = "/api/v1/pods"
+    pvcsEndpoint     = "/api/v1/persistentvolumeclaims"
+    pvsEndpoint      = "/api/v1/persistentvolumes"
 )
 
+// 事件写入其关联对象所在的 namespace
 func postEvent(event Event) error {
-    var b []byte
-    body := bytes.NewBuffer(b)
-    err := json.NewEncoder(body).Encode(event)
-    if err != nil {
-        return err
+    namespace := event.InvolvedObject.Namespace
+    if namespace == "" {
+        namespace = "default"
     }
-
-    request := &http.Request{
-        Body:          ioutil.NopCloser(body),
-        ContentLength: int64(body.Len()),
-        Header:        make(http.Header),
-        Method:        http.MethodPost,
-        URL: &url.URL{
-            Host:   apiHost,
-            Path:   eventsEndpoint,
-            Scheme: "http",
-        },
-    }
-    request.Header.Set("Content-Type", "application/json")
-
-    resp, err := http.DefaultClient.Do(request)
+    err := client.Post(fmt.Sprintf(eventsEndpoint, namespace), event)
     if err != nil {
-        return err
-    }
-    if resp.StatusCode != 201 {
-        return errors.New("Event: Unexpected HTTP status code" + resp.Status)
+        return errors.New("Event: " + err.Error())
     }
     return nil
 }
 
 func getNodes() (*NodeList, error) {
     var nodeList NodeList
-
-    request := &http.Request{
-        Header: make(http.Header),
-        Method: http.MethodGet,
-        URL: &url.URL{
-            Host:   apiHost,
-            Path:   nodesEndpoint,
-            Scheme: "http",
-        },
-    }
-    request.Header.Set("Accept", "application/json, */*")
-
-    resp, err := http.DefaultClient.Do(request)
+    err := client.Get(nodesEndpoint, nil, &nodeList)
     if err != nil {
         return nil, err
     }
+    return &nodeList, nil
+}
 
-    err = json.NewDecoder(resp.Body).Decode(&nodeList)
-    if err != nil {
-        return nil, err
-    }
+// 计入节点资源占用的 pod 查询条件：排除已结束的 pod。
+// fieldSelector 只能出现一次，多个条件之间是“与”的关系，
+// 因此用两个不等条件而不是 Running、Pending 两个相等条件
+func activePodsQuery() url.Values {
+    v := url.Values{}
+    v.Set("fieldSelector", "status.phase!="+podSucceeded+",status.phase!="+podFailed)
+    return v
+}
 
-    return &nodeList, nil
+func isTerminated(pod *Pod) bool {
+    return pod.Status.Phase == podSucceeded || pod.Status.Phase == podFailed
 }
 
 func getPods() (*PodList, error) {
     var podList PodList
 
-    v := url.Values{}
-    v.Add("fieldSelector", "status.phase=Running")
-    v.Add("fieldSelector", "status.phase=Pending")
-
-    request := &http.Request{
-        Header: make(http.Header),
-        Method: http.MethodGet,
-        URL: &url.URL{
-            Host:     apiHost,
-            Path:     podsEndpoint,
-            RawQuery: v.Encode(),
-            Scheme:   "http",
-        },
+    err := client.Get(podsEndpoint, activePodsQuery(), &podList)
+    if err != nil {
+        return nil, err
     }
-    request.Header.Set("Accept", "application/json, */*")
+    return &podList, nil
+}
 
-    resp, err := http.DefaultClient.Do(request)
+func getPersistentVolumeClaims() (*PersistentVolumeClaimList, error) {
+    var pvcList PersistentVolumeClaimList
+    err := client.Get(pvcsEndpoint, nil, &pvcList)
     if err != nil {
         return nil, err
     }
-    err = json.NewDecoder(resp.Body).Decode(&podList)
+    return &pvcList, nil
+}
+
+func getPersistentVolumes() (*PersistentVolumeList, error) {
+    var pvList PersistentVolumeList
+    err := client.Get(pvsEndpoint, nil, &pvList)
     if err != nil {
         return nil, err
     }
-    return &podList, nil
+    return &pvList, nil
+}
+
+func podNamespace(pod *Pod) string {
+    if pod.Metadata.Namespace == "" {
+        return "default"
+    }
+    return pod.Metadata.Namespace
+}
+
+// 构造与 pod 关联的 Kubernetes 事件
+func newPodEvent(pod *Pod, eventType, reason, message string) Event {
+    timestamp := time.Now().UTC().Format(time.RFC3339)
+    return Event{
+        Count:          1,
+        Message:        message,
+        Metadata:       Metadata{GenerateName: pod.Metadata.Name + "-", Namespace: podNamespace(pod)},
+        Reason:         reason,
+        LastTimestamp:  timestamp,
+        FirstTimestamp: timestamp,
+        Type:           eventType,
+        Source:         EventSource{Component: "hightower-scheduler"},
+        InvolvedObject: ObjectReference{
+            Kind:      "Pod",
+            Name:      pod.Metadata.Name,
+            Namespace: podNamespace(pod),
+            Uid:       pod.Metadata.Uid,
+        },
+    }
 }
 
 func errFatal(err error, msg string) {
@@ -121,8 +129,18 @@ func errPrintln(err error, msg string) {
 }
 
 func printResourceUsage(ru ResourceUsage, node *Node, msg string) {
+    names := make([]string, 0, len(ru))
+    for name := range ru {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    usage := make([]string, 0, len(names))
+    for _, name := range names {
+        usage = append(usage, fmt.Sprintf("%s: [%d]", name, ru[name]))
+    }
     log.Print("node - " + node.Metadata.Name + "\t" + msg + ":\t")
-    log.Printf("CPU: [%d] Memory: [%d] Pod: [%d]\n", ru.CPU, ru.Memory, ru.Pod)
+    log.Println(strings.Join(usage, " "))
 }
 
 func printNodeScores(nodeScore map[*Node]float64) {
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "testing"
+)
+
+func TestBindPostsToPodNamespace(t *testing.T) {
+    f := newFakeAPI(t)
+    pod := pendingPod("team", "web", "1")
+    node := &Node{}
+    node.Metadata.Name = "node-1"
+
+    if err := bind(pod, node, nil); err != nil {
+        t.Fatal(err)
+    }
+
+    bindings := f.requestsTo(http.MethodPost, "/api/v1/namespaces/team/pods/web/binding/")
+    if len(bindings) != 1 {
+        t.Fatalf("got %d binding requests, want 1", len(bindings))
+    }
+    var binding Binding
+    if err := json.Unmarshal([]byte(bindings[0].body), &binding); err != nil {
+        t.Fatal(err)
+    }
+    if binding.Metadata.Namespace != "team" || binding.Target.Name != "node-1" {
+        t.Errorf("binding = %+v, want team/web -> node-1", binding)
+    }
+
+    events := f.requestsTo(http.MethodPost, "/api/v1/namespaces/team/events")
+    if len(events) != 1 {
+        t.Fatalf("got %d events in team, want 1", len(events))
+    }
+    var event Event
+    if err := json.Unmarshal([]byte(events[0].body), &event); err != nil {
+        t.Fatal(err)
+    }
+    if event.InvolvedObject.Namespace != "team" || event.Reason != "Scheduled" {
+        t.Errorf("event = %+v, want Scheduled for team/web", event)
+    }
+    if n := len(f.requestsTo(http.MethodPost, "/api/v1/namespaces/default/")); n != 0 {
+        t.Errorf("got %d requests to the default namespace, want 0", n)
+    }
+}
+
+func TestPostEventDefaultsNamespace(t *testing.T) {
+    f := newFakeAPI(t)
+    pod := pendingPod("", "web", "1")
+
+    if err := postEvent(newPodEvent(pod, "Warning", "FailedScheduling", "no fit")); err != nil {
+        t.Fatal(err)
+    }
+    if n := len(f.requestsTo(http.MethodPost, "/api/v1/namespaces/default/events")); n != 1 {
+        t.Errorf("got %d events in default, want 1", n)
+    }
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+    "fmt"
+)
+
+// topologySpreadConstraints 返回 pod 上指定 whenUnsatisfiable 的分布约束
+func topologySpreadConstraints(pod *Pod, whenUnsatisfiable string) []TopologySpreadConstraint {
+    var constraints []TopologySpreadConstraint
+    for _, c := range pod.Spec.TopologySpreadConstraints {
+        if c.WhenUnsatisfiable == whenUnsatisfiable {
+            constraints = append(constraints, c)
+        }
+    }
+    return constraints
+}
+
+// spreadCounts 记录每条约束下各拓扑域中匹配的 pod 数
+type spreadCounts []map[string]int64
+
+// countTopologySpread 统计各约束在各拓扑域中已有的匹配 pod 数。
+// 只有满足 pod 节点亲和性且带有全部 topologyKey 标签的节点参与统计，
+// 这样的节点即使没有匹配 pod 也作为计数为 0 的拓扑域
+func countTopologySpread(pod *Pod, constraints []TopologySpreadConstraint, nodes []*Node, existing []scheduledPod) spreadCounts {
+    counts := make(spreadCounts, len(constraints))
+    for i := range constraints {
+        counts[i] = make(map[string]int64)
+    }
+    if len(constraints) == 0 {
+        return counts
+    }
+
+    eligible := make(map[string]bool)
+    for _, node := range nodes {
+        if !hasTopologyKeys(node, constraints) || fitsNodeAffinity(pod, node) != nil {
+            continue
+        }
+        eligible[node.Metadata.Name] = true
+        for i, c := range constraints {
+            value := node.Metadata.Labels[c.TopologyKey]
+            if _, ok := counts[i][value]; !ok {
+                counts[i][value] = 0
+            }
+        }
+    }
+
+    for _, e := range existing {
+        if !eligible[e.node.Metadata.Name] || podNamespace(e.pod) != podNamespace(pod) {
+            continue
+        }
+        for i, c := range constraints {
+            if matchLabelSelector(c.LabelSelector, e.pod.Metadata.Labels) {
+                counts[i][e.node.Metadata.Labels[c.TopologyKey]]++
+            }
+        }
+    }
+    return counts
+}
+
+func hasTopologyKeys(node *Node, constraints []TopologySpreadConstraint) bool {
+    for _, c := range constraints {
+        if _, ok := node.Metadata.Labels[c.TopologyKey]; !ok {
+            return false
+        }
+    }
+    return true
+}
+
+// fitsTopologySpread 检查 pod 放到节点后，每条 DoNotSchedule 约束的偏差不超过 maxSkew
+func fitsTopologySpread(pod *Pod, node *Node, constraints []TopologySpreadConstraint, counts spreadCounts) error {
+    for i, c := range constraints {
+        value, ok := node.Metadata.Labels[c.TopologyKey]
+        if !ok {
+            return fmt.Errorf("node doesn't have topology label %s", c.TopologyKey)
+        }
+
+        var min int64 = -1
+        for _, count := range counts[i] {
+            if min < 0 || count < min {
+                min = count
+            }
+        }
+        if min < 0 {
+            min = 0
+        }
+
+        // pod 自身匹配选择器时，放置后所在拓扑域计数加一
+        self := int64(0)
+        if matchLabelSelector(c.LabelSelector, pod.Metadata.Labels) {
+            self = 1
+        }
+        if skew := counts[i][value] + self - min; skew > c.MaxSkew {
+            return fmt.Errorf("node didn't satisfy topology spread constraint on %s: skew %d exceeds maxSkew %d", c.TopologyKey, skew, c.MaxSkew)
+        }
+    }
+    return nil
+}
+
+// topologySpreadRawScore 累加节点所在拓扑域中匹配的 pod 数，maxSkew 越大约束越宽松，
+// 得分越低越好，由 normalizeTopologySpreadScores 反转并归一化
+func topologySpreadRawScore(node *Node, constraints []TopologySpreadConstraint, counts spreadCounts) int64 {
+    var raw int64
+    for i, c := range constraints {
+        maxSkew := c.MaxSkew
+        if maxSkew < 1 {
+            maxSkew = 1
+        }
+        raw += counts[i][node.Metadata.Labels[c.TopologyKey]] * maxNodeScore / maxSkew
+    }
+    return raw
+}
+
+// normalizeTopologySpreadScores 匹配 pod 越少得分越高，所有节点相同时都视为理想，
+// 缺少 topologyKey 标签的节点得 0 分
+func normalizeTopologySpreadScores(constraints []TopologySpreadConstraint, scores map[*Node]int64) {
+    first := true
+    var min, max int64
+    for node, score := range scores {
+        if !hasTopologyKeys(node, constraints) {
+            continue
+        }
+        if first || score < min {
+            min = score
+        }
+        if first || score > max {
+            max = score
+        }
+        first = false
+    }
+    for node, score := range scores {
+        switch {
+        case !hasTopologyKeys(node, constraints):
+            scores[node] = 0
+        case max > min:
+            scores[node] = maxNodeScore * (max - score) / (max - min)
+        default:
+            scores[node] = maxNodeScore
+        }
+    }
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+var (
+    spreadA1    = labeledNode("a1", map[string]string{"zone": "a"})
+    spreadB1    = labeledNode("b1", map[string]string{"zone": "b"})
+    spreadC1    = labeledNode("c1", map[string]string{"zone": "c"})
+    spreadBare  = labeledNode("bare", nil)
+    spreadNodes = []*Node{spreadA1, spreadB1, spreadC1, spreadBare}
+)
+
+var zoneSpread = []TopologySpreadConstraint{{
+    MaxSkew:           1,
+    TopologyKey:       "zone",
+    WhenUnsatisfiable: doNotSchedule,
+    LabelSelector:     &LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+}}
+
+// spreadPod 只能调度到 zone a、b，zone c 不参与分布统计
+func spreadPod(name, app string) *Pod {
+    pod := labeledPod("default", name, map[string]string{"app": app})
+    pod.Spec.TopologySpreadConstraints = zoneSpread
+    pod.Spec.Affinity = &Affinity{NodeAffinity: &NodeAffinity{
+        RequiredDuringSchedulingIgnoredDuringExecution: &NodeSelector{NodeSelectorTerms: []NodeSelectorTerm{{
+            MatchExpressions: []NodeSelectorRequirement{{Key: "zone", Operator: selectorOpIn, Values: []string{"a", "b"}}},
+        }}},
+    }}
+    return pod
+}
+
+func TestCountTopologySpread(t *testing.T) {
+    existing := []scheduledPod{
+        {webPod("default", "web-0"), spreadA1},
+        {webPod("default", "web-1"), spreadB1},
+        {webPod("default", "web-2"), spreadA1},
+        // 其他 namespace、不匹配选择器、不满足节点亲和性或缺少拓扑标签的都不计入
+        {webPod("other", "web-3"), spreadB1},
+        {labeledPod("default", "db-0", map[string]string{"app": "db"}), spreadB1},
+        {webPod("default", "web-4"), spreadC1},
+        {webPod("default", "web-5"), spreadBare},
+    }
+    counts := countTopologySpread(spreadPod("web", "web"), zoneSpread, spreadNodes, existing)
+    if want := (spreadCounts{{"a": 2, "b": 1}}); !reflect.DeepEqual(counts, want) {
+        t.Errorf("countTopologySpread() = %v, want %v", counts, want)
+    }
+}
+
+func TestFitsTopologySpread(t *testing.T) {
+    tests := []struct {
+        name   string
+        pod    *Pod
+        node   *Node
+        counts spreadCounts
+        fits   bool
+    }{
+        // 偏差以可调度拓扑域中最少的计数为基准，zone c 不在其中
+        {"balanced", spreadPod("web", "web"), spreadA1, spreadCounts{{"a": 1, "b": 1}}, true},
+        {"too many in zone", spreadPod("web", "web"), spreadA1, spreadCounts{{"a": 2, "b": 1}}, false},
+        {"emptiest zone", spreadPod("web", "web"), spreadB1, spreadCounts{{"a": 2, "b": 1}}, true},
+        {"empty domain sets the minimum", spreadPod("web", "web"), spreadA1, spreadCounts{{"a": 1, "b": 0}}, false},
+        // pod 不匹配自己的选择器时放置后计数不变
+        {"self does not match", spreadPod("api", "api"), spreadA1, spreadCounts{{"a": 2, "b": 1}}, true},
+        {"self does not match over skew", spreadPod("api", "api"), spreadA1, spreadCounts{{"a": 3, "b": 1}}, false},
+        {"missing topology label", spreadPod("web", "web"), spreadBare, spreadCounts{{"a": 0, "b": 0}}, false},
+        {"no domains", spreadPod("web", "web"), spreadA1, spreadCounts{{}}, true},
+    }
+    for _, tt := range tests {
+        err := fitsTopologySpread(tt.pod, tt.node, zoneSpread, tt.counts)
+        if (err == nil) != tt.fits {
+            t.Errorf("%s: fitsTopologySpread() = %v, want fits %v", tt.name, err, tt.fits)
+        }
+    }
+}
+
+func TestTopologySpreadScores(t *testing.T) {
+    tests := []struct {
+        name   string
+        counts spreadCounts
+        want   map[*Node]int64
+    }{
+        {"fewer matching pods score higher", spreadCounts{{"a": 2, "b": 1}},
+            map[*Node]int64{spreadA1: 0, spreadB1: maxNodeScore, spreadBare: 0}},
+        // 缺少拓扑标签的节点不影响其他节点的归一化，自身得 0 分
+        {"all equal", spreadCounts{{"a": 1, "b": 1}},
+            map[*Node]int64{spreadA1: maxNodeScore, spreadB1: maxNodeScore, spreadBare: 0}},
+    }
+    for _, tt := range tests {
+        scores := make(map[*Node]int64)
+        for _, node := range []*Node{spreadA1, spreadB1, spreadBare} {
+            scores[node] = topologySpreadRawScore(node, zoneSpread, tt.counts)
+        }
+        normalizeTopologySpreadScores(zoneSpread, scores)
+        for node, want := range tt.want {
+            if scores[node] != want {
+                t.Errorf("%s: score on %s = %d, want %d", tt.name, node.Metadata.Name, scores[node], want)
+            }
+        }
+    }
+}
@@ -52,27 +52,187 @@ type PodList struct {
     Items      []Pod        `json:"items"`
 }
 
-type PodWatchEvent struct {
-    Type   string `json:"type"`
-    Object Pod    `json:"object"`
+type Pod struct {
+    Kind     string    `json:"kind,omitempty"`
+    Metadata Metadata  `json:"metadata"`
+    Spec     PodSpec   `json:"spec"`
+    Status   PodStatus `json:"status"`
 }
 
-type Pod struct {
-    Kind     string   `json:"kind,omitempty"`
-    Metadata Metadata `json:"metadata"`
-    Spec     PodSpec  `json:"spec"`
+const (
+    podSucceeded = "Succeeded"
+    podFailed    = "Failed"
+)
+
+type PodStatus struct {
+    Phase string `json:"phase,omitempty"`
 }
 
 type PodSpec struct {
-    NodeName   string      `json:"nodeName"`
-    Containers []Container `json:"containers"`
+    NodeName       string       `json:"nodeName"`
+    SchedulerName  string       `json:"schedulerName,omitempty"`
+    InitContainers []Container  `json:"initContainers,omitempty"`
+    Containers     []Container  `json:"containers"`
+    Overhead       ResourceList `json:"overhead,omitempty"`
+
+    NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+    Affinity     *Affinity         `json:"affinity,omitempty"`
+    Tolerations  []Toleration      `json:"tolerations,omitempty"`
+
+    TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+    Volumes []Volume `json:"volumes,omitempty"`
+}
+
+// Volume 只关心通过 PVC 挂载的卷，其余卷类型不影响调度
+type Volume struct {
+    Name                  string                             `json:"name"`
+    PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+}
+
+type PersistentVolumeClaimVolumeSource struct {
+    ClaimName string `json:"claimName"`
+}
+
+type PersistentVolumeClaimList struct {
+    Metadata ListMetadata            `json:"metadata"`
+    Items    []PersistentVolumeClaim `json:"items"`
+}
+
+type PersistentVolumeClaim struct {
+    Metadata Metadata                  `json:"metadata"`
+    Spec     PersistentVolumeClaimSpec `json:"spec"`
+}
+
+type PersistentVolumeClaimSpec struct {
+    // VolumeName 为空表示 PVC 尚未绑定
+    VolumeName string `json:"volumeName,omitempty"`
+}
+
+type PersistentVolumeList struct {
+    Metadata ListMetadata       `json:"metadata"`
+    Items    []PersistentVolume `json:"items"`
+}
+
+type PersistentVolume struct {
+    Metadata Metadata             `json:"metadata"`
+    Spec     PersistentVolumeSpec `json:"spec"`
+}
+
+// PersistentVolumeSpec 只包含用于节点亲和性和挂载数量限制的字段
+type PersistentVolumeSpec struct {
+    NodeAffinity         *VolumeNodeAffinity `json:"nodeAffinity,omitempty"`
+    CSI                  *CSIVolumeSource    `json:"csi,omitempty"`
+    AWSElasticBlockStore *AWSEBSVolumeSource `json:"awsElasticBlockStore,omitempty"`
+    GCEPersistentDisk    *GCEPDVolumeSource  `json:"gcePersistentDisk,omitempty"`
+    AzureDisk            *AzureDiskSource    `json:"azureDisk,omitempty"`
+}
+
+type CSIVolumeSource struct {
+    Driver       string `json:"driver"`
+    VolumeHandle string `json:"volumeHandle"`
+}
+
+type AWSEBSVolumeSource struct {
+    VolumeID string `json:"volumeID"`
+}
+
+type GCEPDVolumeSource struct {
+    PDName string `json:"pdName"`
+}
+
+type AzureDiskSource struct {
+    DiskName string `json:"diskName"`
+}
+
+type VolumeNodeAffinity struct {
+    Required *NodeSelector `json:"required,omitempty"`
+}
+
+const (
+    doNotSchedule  = "DoNotSchedule"
+    scheduleAnyway = "ScheduleAnyway"
+)
+
+// TopologySpreadConstraint 要求匹配 labelSelector 的 pod 在 topologyKey 划分的各拓扑域间数量差不超过 maxSkew
+type TopologySpreadConstraint struct {
+    MaxSkew           int64          `json:"maxSkew"`
+    TopologyKey       string         `json:"topologyKey"`
+    WhenUnsatisfiable string         `json:"whenUnsatisfiable"`
+    LabelSelector     *LabelSelector `json:"labelSelector,omitempty"`
+}
+
+type Affinity struct {
+    NodeAffinity    *NodeAffinity    `json:"nodeAffinity,omitempty"`
+    PodAffinity     *PodAffinity     `json:"podAffinity,omitempty"`
+    PodAntiAffinity *PodAntiAffinity `json:"podAntiAffinity,omitempty"`
+}
+
+type PodAffinity struct {
+    RequiredDuringSchedulingIgnoredDuringExecution  []PodAffinityTerm         `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+    PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+type PodAntiAffinity struct {
+    RequiredDuringSchedulingIgnoredDuringExecution  []PodAffinityTerm         `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+    PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// PodAffinityTerm 描述与哪些 pod（labelSelector + namespaces）处于同一拓扑域（topologyKey）
+type PodAffinityTerm struct {
+    LabelSelector *LabelSelector `json:"labelSelector,omitempty"`
+    Namespaces    []string       `json:"namespaces,omitempty"`
+    TopologyKey   string         `json:"topologyKey"`
+}
+
+type WeightedPodAffinityTerm struct {
+    Weight          int64           `json:"weight"`
+    PodAffinityTerm PodAffinityTerm `json:"podAffinityTerm"`
+}
+
+type LabelSelector struct {
+    MatchLabels      map[string]string         `json:"matchLabels,omitempty"`
+    MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement 与 NodeSelectorRequirement 结构相同，只支持 In、NotIn、Exists、DoesNotExist
+type LabelSelectorRequirement NodeSelectorRequirement
+
+type NodeAffinity struct {
+    RequiredDuringSchedulingIgnoredDuringExecution *NodeSelector `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// NodeSelector 的各个 term 之间是“或”的关系
+type NodeSelector struct {
+    NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms"`
+}
+
+// NodeSelectorTerm 内的所有条件需同时满足
+type NodeSelectorTerm struct {
+    MatchExpressions []NodeSelectorRequirement `json:"matchExpressions,omitempty"`
+    MatchFields      []NodeSelectorRequirement `json:"matchFields,omitempty"`
+}
+
+type NodeSelectorRequirement struct {
+    Key      string   `json:"key"`
+    Operator string   `json:"operator"`
+    Values   []string `json:"values,omitempty"`
 }
 
 type Container struct {
     Name      string               `json:"name"`
+    Ports     []ContainerPort      `json:"ports,omitempty"`
     Resources ResourceRequirements `json:"resources"`
 }
 
+type ContainerPort struct {
+    Name          string `json:"name,omitempty"`
+    ContainerPort int32  `json:"containerPort"`
+    HostPort      int32  `json:"hostPort,omitempty"`
+    HostIP        string `json:"hostIP,omitempty"`
+    Protocol      string `json:"protocol,omitempty"`
+}
+
 type ResourceRequirements struct {
     Limits   ResourceList `json:"limits"`
     Requests ResourceList `json:"requests"`
@@ -94,19 +254,59 @@ type Target struct {
 }
 
 type NodeList struct {
-    ApiVersion string `json:"apiVersion"`
-    Kind       string `json:"kind"`
-    Items      []*Node
+    ApiVersion string       `json:"apiVersion"`
+    Kind       string       `json:"kind"`
+    Metadata   ListMetadata `json:"metadata"`
+    Items      []*Node      `json:"items"`
 }
 
 type Node struct {
     Metadata Metadata   `json:"metadata"`
+    Spec     NodeSpec   `json:"spec"`
     Status   NodeStatus `json:"status"`
 }
 
+type NodeSpec struct {
+    Unschedulable bool    `json:"unschedulable,omitempty"`
+    Taints        []Taint `json:"taints,omitempty"`
+}
+
+const (
+    taintEffectNoSchedule       = "NoSchedule"
+    taintEffectPreferNoSchedule = "PreferNoSchedule"
+    taintEffectNoExecute        = "NoExecute"
+)
+
+type Taint struct {
+    Key    string `json:"key"`
+    Value  string `json:"value,omitempty"`
+    Effect string `json:"effect"`
+}
+
+const (
+    tolerationOpEqual  = "Equal"
+    tolerationOpExists = "Exists"
+)
+
+type Toleration struct {
+    Key               string `json:"key,omitempty"`
+    Operator          string `json:"operator,omitempty"`
+    Value             string `json:"value,omitempty"`
+    Effect            string `json:"effect,omitempty"`
+    TolerationSeconds *int64 `json:"tolerationSeconds,omitempty"`
+}
+
 type NodeStatus struct {
-    Capacity    ResourceList `json:"capacity"`
-    Allocatable ResourceList `json:"allocatable"`
+    Capacity    ResourceList    `json:"capacity"`
+    Allocatable ResourceList    `json:"allocatable"`
+    Conditions  []NodeCondition `json:"conditions,omitempty"`
+}
+
+type NodeCondition struct {
+    Type    string `json:"type"`
+    Status  string `json:"status"`
+    Reason  string `json:"reason,omitempty"`
+    Message string `json:"message,omitempty"`
 }
 
 type ListMetadata struct {
@@ -115,6 +315,7 @@ type ListMetadata struct {
 
 type Metadata struct {
     Name            string            `json:"name"`
+    Namespace       string            `json:"namespace,omitempty"`
     GenerateName    string            `json:"generateName"`
     ResourceVersion string            `json:"resourceVersion"`
     Labels          map[string]string `json:"labels"`
@@ -122,8 +323,11 @@ type Metadata struct {
     Uid             string            `json:"uid"`
 }
 
-type ResourceUsage struct {
-    CPU int64
-    Memory int64
-    Pod int64
-}
+const (
+    resourceCPU    = "cpu"
+    resourceMemory = "memory"
+    resourcePods   = "pods"
+)
+
+// ResourceUsage 按资源名记录数量，CPU 以毫核计，其余资源以基本单位计
+type ResourceUsage map[string]int64
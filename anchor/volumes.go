@@ -0,0 +1,140 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+)
+
+const attachableVolumesPrefix = "attachable-volumes-"
+
+// podVolumes 是调度一个 pod 时从快照中解析出的 PVC 与 PV
+type podVolumes struct {
+    // pvs 为待调度 pod 已绑定的 PV
+    pvs []*PersistentVolume
+    // claims 以 namespace/name 为键，volumes 以 PV 名为键，用于统计节点上已挂载的卷
+    claims  map[string]*PersistentVolumeClaim
+    volumes map[string]*PersistentVolume
+}
+
+// resolvePodVolumes 用快照中的 PVC 和 PV 把 pod 的 PVC 解析为 PV。PVC 不存在时 pod 无法调度；
+// 尚未绑定的 PVC 由 provisioner 按调度结果创建卷，不限制节点
+func resolvePodVolumes(pod *Pod, snapshot *clusterSnapshot) (*podVolumes, error) {
+    pv := &podVolumes{
+        claims:  snapshot.claims,
+        volumes: snapshot.volumes,
+    }
+    for _, v := range pod.Spec.Volumes {
+        if v.PersistentVolumeClaim == nil {
+            continue
+        }
+        key := podNamespace(pod) + "/" + v.PersistentVolumeClaim.ClaimName
+        pvc, ok := pv.claims[key]
+        if !ok {
+            return nil, fmt.Errorf("persistentvolumeclaim %s not found", key)
+        }
+        if pvc.Spec.VolumeName == "" {
+            continue
+        }
+        volume, ok := pv.volumes[pvc.Spec.VolumeName]
+        if !ok {
+            return nil, fmt.Errorf("persistentvolume %s bound to claim %s not found", pvc.Spec.VolumeName, key)
+        }
+        pv.pvs = append(pv.pvs, volume)
+    }
+    return pv, nil
+}
+
+// fitsVolumeNodeAffinity 要求节点满足 pod 所用每个 PV 的 nodeAffinity，
+// 例如区域磁盘所在的 zone 或本地卷所在的节点
+func fitsVolumeNodeAffinity(volumes *podVolumes, node *Node) error {
+    for _, pv := range volumes.pvs {
+        if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+            continue
+        }
+        if err := matchNodeSelectorTerms(pv.Spec.NodeAffinity.Required.NodeSelectorTerms, node); err != nil {
+            return fmt.Errorf("node didn't match persistentvolume %s node affinity: %v", pv.Metadata.Name, err)
+        }
+    }
+    return nil
+}
+
+// volumeLimitKey 返回 PV 计入的节点 allocatable 资源名及卷的唯一标识，
+// 不受挂载数量限制的卷类型返回空字符串
+func volumeLimitKey(pv *PersistentVolume) (string, string) {
+    switch {
+    case pv.Spec.CSI != nil:
+        return attachableVolumesPrefix + "csi-" + pv.Spec.CSI.Driver, pv.Spec.CSI.VolumeHandle
+    case pv.Spec.AWSElasticBlockStore != nil:
+        return attachableVolumesPrefix + "aws-ebs", pv.Spec.AWSElasticBlockStore.VolumeID
+    case pv.Spec.GCEPersistentDisk != nil:
+        return attachableVolumesPrefix + "gce-pd", pv.Spec.GCEPersistentDisk.PDName
+    case pv.Spec.AzureDisk != nil:
+        return attachableVolumesPrefix + "azure-disk", pv.Spec.AzureDisk.DiskName
+    }
+    return "", ""
+}
+
+// attachedVolumes 统计节点上已有 pod 挂载的卷，按限制类型分组并去重
+func attachedVolumes(node *Node, existing []scheduledPod, volumes *podVolumes) map[string]map[string]bool {
+    attached := make(map[string]map[string]bool)
+    for _, e := range existing {
+        if e.node != node {
+            continue
+        }
+        for _, v := range e.pod.Spec.Volumes {
+            if v.PersistentVolumeClaim == nil {
+                continue
+            }
+            pvc, ok := volumes.claims[podNamespace(e.pod)+"/"+v.PersistentVolumeClaim.ClaimName]
+            if !ok || pvc.Spec.VolumeName == "" {
+                continue
+            }
+            pv, ok := volumes.volumes[pvc.Spec.VolumeName]
+            if !ok {
+                continue
+            }
+            if key, id := volumeLimitKey(pv); key != "" {
+                if attached[key] == nil {
+                    attached[key] = make(map[string]bool)
+                }
+                attached[key][id] = true
+            }
+        }
+    }
+    return attached
+}
+
+// fitsVolumeLimits 检查挂载 pod 的卷后不超过节点 allocatable 中 attachable-volumes-* 的上限
+func fitsVolumeLimits(volumes *podVolumes, node *Node, existing []scheduledPod) error {
+    if len(volumes.pvs) == 0 {
+        return nil
+    }
+    allocatable, err := nodeAllocatable(node)
+    if err != nil {
+        return err
+    }
+
+    attached := attachedVolumes(node, existing, volumes)
+    var exceeded []string
+    for _, pv := range volumes.pvs {
+        key, id := volumeLimitKey(pv)
+        if key == "" {
+            continue
+        }
+        limit, ok := allocatable[key]
+        if !ok {
+            continue
+        }
+        if attached[key] == nil {
+            attached[key] = make(map[string]bool)
+        }
+        attached[key][id] = true
+        if int64(len(attached[key])) > limit && !containsString(exceeded, key) {
+            exceeded = append(exceeded, key)
+        }
+    }
+    if len(exceeded) > 0 {
+        return fmt.Errorf("node exceeded max volume count: %s", strings.Join(exceeded, ", "))
+    }
+    return nil
+}
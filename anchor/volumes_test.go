@@ -0,0 +1,68 @@
+package main
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+)
+
+const twoNodes = `{"metadata": {"resourceVersion": "1"}, "items": [
+    {"metadata": {"name": "node-a", "labels": {"zone": "a"}}, "status": {"allocatable": {"cpu": "4", "memory": "8Gi", "pods": "110"}}},
+    {"metadata": {"name": "node-b", "labels": {"zone": "b"}}, "status": {"allocatable": {"cpu": "4", "memory": "8Gi", "pods": "110"}}}
+]}`
+
+// 调度使用 PVC 的 pod 时从缓存读取 PVC 和 PV，不再每次 List
+func TestSchedulePodReadsVolumesFromCache(t *testing.T) {
+    f := newFakeAPI(t)
+    f.set(nodesEndpoint, twoNodes)
+    f.set(podsEndpoint, emptyList)
+    f.set(pvcsEndpoint, `{"metadata": {"resourceVersion": "1"}, "items": [
+        {"metadata": {"name": "data", "namespace": "team"}, "spec": {"volumeName": "pv-b"}}
+    ]}`)
+    f.set(pvsEndpoint, `{"metadata": {"resourceVersion": "1"}, "items": [
+        {"metadata": {"name": "pv-b"}, "spec": {"nodeAffinity": {"required": {"nodeSelectorTerms": [
+            {"matchExpressions": [{"key": "zone", "operator": "In", "values": ["b"]}]}
+        ]}}}}
+    ]}`)
+    startCache(t)
+    useDefaultProfile(t)
+
+    for i, name := range []string{"db-0", "db-1"} {
+        pod := pendingPod("team", name, "100m")
+        pod.Spec.Volumes = []Volume{{Name: "data", PersistentVolumeClaim: &PersistentVolumeClaimVolumeSource{ClaimName: "data"}}}
+        if err := schedulePod(pod); err != nil {
+            t.Fatalf("schedulePod(%s) = %v", name, err)
+        }
+        bindings := f.requestsTo(http.MethodPost, "/api/v1/namespaces/team/pods/")
+        if len(bindings) != i+1 {
+            t.Fatalf("got %d binding requests, want %d", len(bindings), i+1)
+        }
+        if want := `"name":"node-b"`; !strings.Contains(bindings[i].body, want) {
+            t.Errorf("%s bound with %s, want target %s", name, bindings[i].body, want)
+        }
+    }
+
+    for _, path := range []string{pvcsEndpoint, pvsEndpoint} {
+        lists := 0
+        for _, r := range f.requestsTo(http.MethodGet, path) {
+            if r.query.Get("watch") != "true" {
+                lists++
+            }
+        }
+        if lists != 1 {
+            t.Errorf("%s listed %d times, want 1", path, lists)
+        }
+    }
+}
+
+func TestResolvePodVolumesMissingClaim(t *testing.T) {
+    snapshot := &clusterSnapshot{
+        claims:  map[string]*PersistentVolumeClaim{},
+        volumes: map[string]*PersistentVolume{},
+    }
+    pod := pendingPod("team", "db-0", "100m")
+    pod.Spec.Volumes = []Volume{{Name: "data", PersistentVolumeClaim: &PersistentVolumeClaimVolumeSource{ClaimName: "data"}}}
+    if _, err := resolvePodVolumes(pod, snapshot); err == nil {
+        t.Error("resolvePodVolumes() = nil, want claim not found")
+    }
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "math/rand"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+)
+
+// WatchEvent 是 watch 接口返回的一条事件，Object 由调用方按资源类型解码
+type WatchEvent struct {
+    Type   string          `json:"type"`
+    Object json.RawMessage `json:"object"`
+}
+
+// Status 是 apiserver 返回的错误信息，ERROR 事件的 Object 即为 Status
+type Status struct {
+    Kind    string `json:"kind"`
+    Status  string `json:"status"`
+    Message string `json:"message"`
+    Reason  string `json:"reason"`
+    Code    int    `json:"code"`
+}
+
+var errResourceExpired = errors.New("resource version expired")
+
+// watch 出错后重试前的等待时间
+var watchRetryDelay = 5 * time.Second
+
+// listWatcher 先 List 得到全量对象及其 resourceVersion，再从该版本开始 Watch。
+// 连接断开时从最后见到的版本续传，版本过期（410 Gone）时重新 List。
+type listWatcher struct {
+    name  string
+    path  string
+    query url.Values
+    // list 拉取全量对象并返回列表的 resourceVersion
+    list func() (string, error)
+    // handle 处理 ADDED、MODIFIED、DELETED 事件
+    handle func(eventType string, object json.RawMessage) error
+}
+
+func (lw *listWatcher) run(done <-chan struct{}, errc chan<- error) {
+    ctx, cancel := context.WithCancel(context.Background())
+    go func() {
+        <-done
+        cancel()
+    }()
+
+    var resourceVersion string
+    for {
+        var err error
+        if resourceVersion == "" {
+            resourceVersion, err = lw.list()
+            // 没有版本无法开始 watch，按出错处理以免不停地重新 List
+            if err == nil && resourceVersion == "" {
+                err = errors.New("list returned no resourceVersion")
+            }
+        } else {
+            resourceVersion, err = lw.watch(ctx, resourceVersion)
+        }
+
+        select {
+        case <-done:
+            return
+        default:
+        }
+
+        if err == errResourceExpired {
+            log.Printf("%s watch resource version expired, relisting", lw.name)
+            resourceVersion = ""
+            continue
+        }
+        if err != nil {
+            select {
+            case errc <- fmt.Errorf("%s watch: %v", lw.name, err):
+            case <-done:
+                return
+            }
+            select {
+            case <-time.After(watchRetryDelay):
+            case <-done:
+                return
+            }
+        }
+    }
+}
+
+// watch 从 resourceVersion 开始监听，返回最后处理到的版本
+func (lw *listWatcher) watch(ctx context.Context, resourceVersion string) (string, error) {
+    v := url.Values{}
+    for key, values := range lw.query {
+        v[key] = values
+    }
+    v.Set("watch", "true")
+    v.Set("resourceVersion", resourceVersion)
+    v.Set("allowWatchBookmarks", "true")
+    // 让 apiserver 定期断开，避免连接被中间设备静默丢弃
+    v.Set("timeoutSeconds", strconv.Itoa(300+rand.Intn(300)))
+
+    request, err := client.NewRequest(http.MethodGet, lw.path, v, nil)
+    if err != nil {
+        return resourceVersion, err
+    }
+    resp, err := client.Do(request.WithContext(ctx))
+    if err != nil {
+        return resourceVersion, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusGone {
+        return "", errResourceExpired
+    }
+    if resp.StatusCode != http.StatusOK {
+        return resourceVersion, errors.New("Invalid status code: " + resp.Status)
+    }
+
+    decoder := json.NewDecoder(resp.Body)
+    for {
+        var event WatchEvent
+        err = decoder.Decode(&event)
+        if err != nil {
+            // 服务端超时正常关闭连接时从当前版本续传
+            if ctx.Err() != nil || err == io.EOF {
+                return resourceVersion, nil
+            }
+            return resourceVersion, err
+        }
+
+        if event.Type == "ERROR" {
+            var status Status
+            if err := json.Unmarshal(event.Object, &status); err != nil {
+                return resourceVersion, err
+            }
+            if status.Code == http.StatusGone {
+                return "", errResourceExpired
+            }
+            return resourceVersion, errors.New(status.Message)
+        }
+
+        var meta struct {
+            Metadata ListMetadata `json:"metadata"`
+        }
+        if err := json.Unmarshal(event.Object, &meta); err != nil {
+            return resourceVersion, err
+        }
+
+        switch event.Type {
+        case "ADDED", "MODIFIED", "DELETED":
+            if err := lw.handle(event.Type, event.Object); err != nil {
+                log.Printf("%s watch: failed to handle %s event: %v", lw.name, event.Type, err)
+            }
+        case "BOOKMARK":
+        default:
+            log.Printf("%s watch: ignoring unknown event type %s", lw.name, event.Type)
+        }
+        if meta.Metadata.ResourceVersion != "" {
+            resourceVersion = meta.Metadata.ResourceVersion
+        }
+    }
+}
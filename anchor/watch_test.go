@@ -0,0 +1,177 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+    "testing"
+    "time"
+)
+
+// 依次模拟：事件流中途断开、BOOKMARK、ERROR 410、HTTP 410，
+// 检查每次 watch 的起始版本以及过期后重新 List
+func TestListWatcherResumesAndRelists(t *testing.T) {
+    f := newFakeAPI(t)
+    old := watchRetryDelay
+    watchRetryDelay = 10 * time.Millisecond
+    t.Cleanup(func() { watchRetryDelay = old })
+
+    var mu sync.Mutex
+    var versions, handled []string
+    lists := 0
+    resumed := make(chan struct{})
+
+    steps := []func(w http.ResponseWriter, r *http.Request){
+        // 一个完整事件之后连接在下一个事件中间断开
+        func(w http.ResponseWriter, r *http.Request) {
+            fmt.Fprintln(w, `{"type": "ADDED", "object": {"metadata": {"name": "a", "resourceVersion": "2"}}}`)
+            fmt.Fprint(w, `{"type": "MODIFIED", "object": {"metadata": {"name": "a", "resourceVersion": "3"`)
+        },
+        func(w http.ResponseWriter, r *http.Request) {
+            fmt.Fprintln(w, `{"type": "BOOKMARK", "object": {"metadata": {"resourceVersion": "4"}}}`)
+        },
+        func(w http.ResponseWriter, r *http.Request) {
+            fmt.Fprintln(w, `{"type": "ERROR", "object": {"kind": "Status", "code": 410, "reason": "Expired"}}`)
+        },
+        func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusGone)
+        },
+        func(w http.ResponseWriter, r *http.Request) {
+            close(resumed)
+            <-r.Context().Done()
+        },
+    }
+    f.watch = func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        step := len(versions)
+        versions = append(versions, r.URL.Query().Get("resourceVersion"))
+        mu.Unlock()
+        if step < len(steps) {
+            steps[step](w, r)
+        }
+    }
+
+    lw := &listWatcher{
+        name: "pods",
+        path: podsEndpoint,
+        list: func() (string, error) {
+            mu.Lock()
+            defer mu.Unlock()
+            lists++
+            if lists == 1 {
+                return "1", nil
+            }
+            return "10", nil
+        },
+        handle: func(eventType string, object json.RawMessage) error {
+            mu.Lock()
+            defer mu.Unlock()
+            handled = append(handled, eventType)
+            return nil
+        },
+    }
+
+    done := make(chan struct{})
+    errc := make(chan error, 10)
+    stopped := make(chan struct{})
+    go func() {
+        lw.run(done, errc)
+        close(stopped)
+    }()
+    select {
+    case <-resumed:
+    case <-time.After(5 * time.Second):
+        t.Fatal("watch did not resume")
+    }
+    close(done)
+    <-stopped
+
+    mu.Lock()
+    defer mu.Unlock()
+    if want := []string{"1", "2", "4", "10", "10"}; fmt.Sprint(versions) != fmt.Sprint(want) {
+        t.Errorf("watch resource versions = %v, want %v", versions, want)
+    }
+    if lists != 3 {
+        t.Errorf("listed %d times, want 3", lists)
+    }
+    if want := []string{"ADDED"}; fmt.Sprint(handled) != fmt.Sprint(want) {
+        t.Errorf("handled events = %v, want %v", handled, want)
+    }
+    select {
+    case <-errc:
+    default:
+        t.Error("truncated stream was not reported")
+    }
+}
+
+// List 成功但没有 resourceVersion 时按出错处理，等待 watchRetryDelay 后重试
+func TestListWatcherRetriesListWithoutResourceVersion(t *testing.T) {
+    f := newFakeAPI(t)
+    old := watchRetryDelay
+    watchRetryDelay = 200 * time.Millisecond
+    t.Cleanup(func() { watchRetryDelay = old })
+
+    watching := make(chan string, 1)
+    f.watch = func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case watching <- r.URL.Query().Get("resourceVersion"):
+        default:
+        }
+        <-r.Context().Done()
+    }
+
+    var mu sync.Mutex
+    lists := 0
+    listCount := func() int {
+        mu.Lock()
+        defer mu.Unlock()
+        return lists
+    }
+    lw := &listWatcher{
+        name: "pods",
+        path: podsEndpoint,
+        list: func() (string, error) {
+            mu.Lock()
+            defer mu.Unlock()
+            lists++
+            if lists < 3 {
+                return "", nil
+            }
+            return "7", nil
+        },
+        handle: func(eventType string, object json.RawMessage) error { return nil },
+    }
+
+    done := make(chan struct{})
+    errc := make(chan error, 10)
+    stopped := make(chan struct{})
+    go func() {
+        lw.run(done, errc)
+        close(stopped)
+    }()
+    defer func() {
+        close(done)
+        <-stopped
+    }()
+
+    time.Sleep(watchRetryDelay / 2)
+    if n := listCount(); n != 1 {
+        t.Fatalf("listed %d times before the retry delay, want 1", n)
+    }
+
+    select {
+    case rv := <-watching:
+        if rv != "7" {
+            t.Errorf("watch resourceVersion = %q, want 7", rv)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("watch did not start")
+    }
+    if n := listCount(); n != 3 {
+        t.Errorf("listed %d times, want 3", n)
+    }
+    if n := len(errc); n != 2 {
+        t.Errorf("reported %d errors, want 2", n)
+    }
+}
@@ -0,0 +1,227 @@
+// annotator 管理节点的价格注解，供调度器的 NodePrice 插件使用，
+// 通过 kube 包与调度器共用同一个 API 客户端
+package main
+
+import (
+    "encoding/csv"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "math/rand"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
+)
+
+// -seed 随机选取的价格
+var seedPrices = []float64{0.05, 0.10, 0.20, 0.40, 0.80, 1.60}
+
+type nodeList struct {
+    Items []node `json:"items"`
+}
+
+type node struct {
+    Metadata struct {
+        Name        string            `json:"name"`
+        Annotations map[string]string `json:"annotations"`
+    } `json:"metadata"`
+}
+
+// nodePatch 是修改节点注解的 merge patch
+type nodePatch struct {
+    Metadata struct {
+        Annotations map[string]string `json:"annotations"`
+    } `json:"metadata"`
+}
+
+func main() {
+    kubeconfig := flag.String("kubeconfig", "", "path to a kubeconfig file, defaults to $KUBECONFIG or the in-cluster service account")
+    context := flag.String("context", "", "kubeconfig context to use, defaults to current-context")
+    master := flag.String("master", "", "address of the API server, overrides the kubeconfig server")
+    annotation := flag.String("annotation", kube.PriceAnnotation, "annotation holding the node price")
+    list := flag.Bool("l", false, "list nodes and their prices")
+    set := flag.String("set", "", "comma separated node=price pairs to annotate")
+    importFile := flag.String("import", "", "CSV file of node,price rows to annotate, - for stdin")
+    seed := flag.Bool("seed", false, "annotate every node with a random price")
+    dryRun := flag.Bool("dry-run", false, "print the changes without annotating nodes")
+    flag.Parse()
+
+    actions := 0
+    for _, enabled := range []bool{*list, *set != "", *importFile != "", *seed} {
+        if enabled {
+            actions++
+        }
+    }
+    if actions != 1 {
+        fmt.Fprintln(os.Stderr, "exactly one of -l, -set, -import or -seed is required")
+        flag.Usage()
+        os.Exit(2)
+    }
+
+    client, err := kube.NewClient(*kubeconfig, *context, *master)
+    if err != nil {
+        log.Fatalf("failed to create API client: %v", err)
+    }
+
+    var nodes nodeList
+    if err := client.Get(kube.NodesEndpoint, nil, &nodes); err != nil {
+        log.Fatalf("failed to list nodes: %v", err)
+    }
+    sort.Slice(nodes.Items, func(i, j int) bool {
+        return nodes.Items[i].Metadata.Name < nodes.Items[j].Metadata.Name
+    })
+
+    if *list {
+        listPrices(os.Stdout, nodes.Items, *annotation)
+        return
+    }
+
+    var prices map[string]float64
+    switch {
+    case *set != "":
+        prices, err = parsePricePairs(*set)
+    case *importFile != "":
+        prices, err = importPrices(*importFile)
+    case *seed:
+        prices = randomPrices(nodes.Items)
+    }
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    if err := annotate(client, os.Stdout, nodes.Items, prices, *annotation, *dryRun); err != nil {
+        log.Fatal(err)
+    }
+}
+
+func parsePrice(s string) (float64, error) {
+    price, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+    if err != nil || price < 0 {
+        return 0, fmt.Errorf("invalid price %q", s)
+    }
+    return price, nil
+}
+
+// parsePricePairs 解析 node=price,node=price 形式的参数
+func parsePricePairs(s string) (map[string]float64, error) {
+    prices := make(map[string]float64)
+    for _, pair := range strings.Split(s, ",") {
+        kv := strings.SplitN(pair, "=", 2)
+        if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+            return nil, fmt.Errorf("invalid node=price pair %q", pair)
+        }
+        price, err := parsePrice(kv[1])
+        if err != nil {
+            return nil, err
+        }
+        prices[strings.TrimSpace(kv[0])] = price
+    }
+    return prices, nil
+}
+
+// importPrices 从文件或标准输入（-）读取价格
+func importPrices(path string) (map[string]float64, error) {
+    var r io.Reader = os.Stdin
+    if path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            return nil, err
+        }
+        defer f.Close()
+        r = f
+    }
+    return readPrices(r, path)
+}
+
+// readPrices 读取 node,price 格式的 CSV，首行价格无法解析时视为表头，# 开头的行为注释，
+// name 用于错误信息
+func readPrices(r io.Reader, name string) (map[string]float64, error) {
+    reader := csv.NewReader(r)
+    reader.Comment = '#'
+    reader.FieldsPerRecord = 2
+    reader.TrimLeadingSpace = true
+    records, err := reader.ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %v", name, err)
+    }
+
+    prices := make(map[string]float64)
+    for i, record := range records {
+        price, err := parsePrice(record[1])
+        if err != nil {
+            if i == 0 {
+                continue
+            }
+            return nil, fmt.Errorf("%s: row %d: %v", name, i+1, err)
+        }
+        prices[strings.TrimSpace(record[0])] = price
+    }
+    return prices, nil
+}
+
+func randomPrices(nodes []node) map[string]float64 {
+    r := rand.New(rand.NewSource(time.Now().UnixNano()))
+    prices := make(map[string]float64)
+    for _, n := range nodes {
+        prices[n.Metadata.Name] = seedPrices[r.Intn(len(seedPrices))]
+    }
+    return prices
+}
+
+// listPrices 输出每个节点的价格，没有注解的节点输出 -
+func listPrices(out io.Writer, nodes []node, annotation string) {
+    for _, n := range nodes {
+        price, ok := n.Metadata.Annotations[annotation]
+        if !ok {
+            price = "-"
+        }
+        fmt.Fprintf(out, "%s %s\n", n.Metadata.Name, price)
+    }
+}
+
+// annotate 把价格写入节点注解并向 out 输出变化，价格未变化的节点跳过。
+// 先检查所有节点都存在，避免只更新了一部分
+func annotate(client *kube.Client, out io.Writer, nodes []node, prices map[string]float64, annotation string, dryRun bool) error {
+    current := make(map[string]string)
+    for _, n := range nodes {
+        current[n.Metadata.Name] = n.Metadata.Annotations[annotation]
+    }
+
+    names := make([]string, 0, len(prices))
+    for name := range prices {
+        if _, ok := current[name]; !ok {
+            return fmt.Errorf("node %s not found", name)
+        }
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        price := strconv.FormatFloat(prices[name], 'f', -1, 64)
+        old := current[name]
+        if old == "" {
+            old = "-"
+        }
+        if old == price {
+            fmt.Fprintf(out, "%s %s unchanged\n", name, price)
+            continue
+        }
+        if dryRun {
+            fmt.Fprintf(out, "%s %s -> %s (dry run)\n", name, old, price)
+            continue
+        }
+
+        var patch nodePatch
+        patch.Metadata.Annotations = map[string]string{annotation: price}
+        if err := client.Patch(fmt.Sprintf(kube.NodeEndpoint, name), patch); err != nil {
+            return fmt.Errorf("failed to annotate node %s: %v", name, err)
+        }
+        fmt.Fprintf(out, "%s %s -> %s\n", name, old, price)
+    }
+    return nil
+}
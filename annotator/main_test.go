@@ -0,0 +1,159 @@
+package main
+
+import (
+    "bytes"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/yinwoods/k8s-scheduler/kube"
+)
+
+func TestParsePricePairs(t *testing.T) {
+    prices, err := parsePricePairs("node-a=0.1, node-b = 2")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := map[string]float64{"node-a": 0.1, "node-b": 2}; !reflect.DeepEqual(prices, want) {
+        t.Errorf("parsePricePairs() = %v, want %v", prices, want)
+    }
+
+    for _, s := range []string{"", "node-a", "=1", "node-a=", "node-a=x", "node-a=-1", "node-a=1,"} {
+        if prices, err := parsePricePairs(s); err == nil {
+            t.Errorf("parsePricePairs(%q) = %v, want error", s, prices)
+        }
+    }
+}
+
+func TestReadPrices(t *testing.T) {
+    tests := []struct {
+        name string
+        in   string
+        want map[string]float64
+    }{
+        {"header", "node,price\nnode-a,0.1\nnode-b,2\n", map[string]float64{"node-a": 0.1, "node-b": 2}},
+        {"no header", "node-a,0.1\nnode-b, 2\n", map[string]float64{"node-a": 0.1, "node-b": 2}},
+        {"header only", "node,price\n", map[string]float64{}},
+        {"comments", "# prices from the cloud bill\nnode,price\n# spot\nnode-a,0.05\n", map[string]float64{"node-a": 0.05}},
+        {"empty", "", map[string]float64{}},
+    }
+    for _, tt := range tests {
+        got, err := readPrices(strings.NewReader(tt.in), "prices.csv")
+        if err != nil {
+            t.Errorf("%s: readPrices() error: %v", tt.name, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: readPrices() = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestReadPricesInvalid(t *testing.T) {
+    tests := []struct {
+        name string
+        in   string
+        err  string
+    }{
+        // 只有首行可以是表头
+        {"bad price", "node-a,0.1\nnode-b,free\n", "prices.csv: row 2: invalid price"},
+        {"negative price", "node,price\nnode-a,-1\n", "prices.csv: row 2: invalid price"},
+        {"missing column", "node-a,0.1\nnode-b\n", "failed to read prices.csv"},
+        {"extra column", "node-a,0.1,usd\n", "failed to read prices.csv"},
+    }
+    for _, tt := range tests {
+        _, err := readPrices(strings.NewReader(tt.in), "prices.csv")
+        if err == nil || !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("%s: readPrices() = %v, want error containing %q", tt.name, err, tt.err)
+        }
+    }
+}
+
+func testNodes() []node {
+    var a, b node
+    a.Metadata.Name = "node-a"
+    a.Metadata.Annotations = map[string]string{kube.PriceAnnotation: "0.1"}
+    b.Metadata.Name = "node-b"
+    return []node{a, b}
+}
+
+func TestListPrices(t *testing.T) {
+    var out bytes.Buffer
+    listPrices(&out, testNodes(), kube.PriceAnnotation)
+    if want := "node-a 0.1\nnode-b -\n"; out.String() != want {
+        t.Errorf("listPrices() output = %q, want %q", out.String(), want)
+    }
+}
+
+// patchRecorder 记录发往 apiserver 的 PATCH 请求
+type patchRecorder struct {
+    mu      sync.Mutex
+    patches map[string]string
+}
+
+func newPatchClient(t *testing.T) (*kube.Client, *patchRecorder) {
+    rec := &patchRecorder{patches: make(map[string]string)}
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := ioutil.ReadAll(r.Body)
+        rec.mu.Lock()
+        defer rec.mu.Unlock()
+        if r.Method != http.MethodPatch {
+            w.WriteHeader(http.StatusMethodNotAllowed)
+            return
+        }
+        rec.patches[r.URL.Path] = strings.TrimSpace(string(body))
+        w.Write([]byte("{}"))
+    }))
+    t.Cleanup(srv.Close)
+    u, err := url.Parse(srv.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return kube.NewClientForServer(u), rec
+}
+
+func TestAnnotate(t *testing.T) {
+    prices := map[string]float64{"node-a": 0.1, "node-b": 0.25}
+    tests := []struct {
+        name    string
+        dryRun  bool
+        out     string
+        patches map[string]string
+    }{
+        {"dry run", true, "node-a 0.1 unchanged\nnode-b - -> 0.25 (dry run)\n", map[string]string{}},
+        {"annotate", false, "node-a 0.1 unchanged\nnode-b - -> 0.25\n", map[string]string{
+            "/api/v1/nodes/node-b": `{"metadata":{"annotations":{"hightower.com/cost":"0.25"}}}`,
+        }},
+    }
+    for _, tt := range tests {
+        client, rec := newPatchClient(t)
+        var out bytes.Buffer
+        if err := annotate(client, &out, testNodes(), prices, kube.PriceAnnotation, tt.dryRun); err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        if out.String() != tt.out {
+            t.Errorf("%s: output = %q, want %q", tt.name, out.String(), tt.out)
+        }
+        if !reflect.DeepEqual(rec.patches, tt.patches) {
+            t.Errorf("%s: patches = %v, want %v", tt.name, rec.patches, tt.patches)
+        }
+    }
+}
+
+// 有未知节点时不修改任何节点
+func TestAnnotateUnknownNode(t *testing.T) {
+    client, rec := newPatchClient(t)
+    var out bytes.Buffer
+    err := annotate(client, &out, testNodes(), map[string]float64{"node-b": 1, "node-c": 2}, kube.PriceAnnotation, false)
+    if err == nil || err.Error() != "node node-c not found" {
+        t.Errorf("annotate() = %v, want node node-c not found", err)
+    }
+    if len(rec.patches) != 0 || out.Len() != 0 {
+        t.Errorf("annotate() patched %v and printed %q before failing", rec.patches, out.String())
+    }
+}
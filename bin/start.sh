@@ -1,4 +1,4 @@
 kubectl delete -f deployments/nginx.yaml
-go build -o scheduler anchor/*.go
+go build -o scheduler ./anchor
 kubectl create -f deployments/nginx.yaml
 ./scheduler
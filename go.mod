@@ -0,0 +1,3 @@
+module github.com/yinwoods/k8s-scheduler
+
+go 1.22
@@ -0,0 +1,13 @@
+// Package kube 是调度器与 annotator 共用的 apiserver 客户端，
+// 包括认证配置、请求封装、YAML 配置解析以及两者共用的常量。
+package kube
+
+const (
+    // NodesEndpoint 列出集群中的所有节点
+    NodesEndpoint = "/api/v1/nodes"
+    // NodeEndpoint 指向单个节点
+    NodeEndpoint = "/api/v1/nodes/%s"
+
+    // PriceAnnotation 记录节点每小时的价格，由 annotator 写入，调度器的 NodePrice 插件读取
+    PriceAnnotation = "hightower.com/cost"
+)
@@ -0,0 +1,409 @@
+package kube
+
+import (
+    "bytes"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+const (
+    serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+    proxyHost         = "127.0.0.1:8080"
+)
+
+// Client 保存访问 apiserver 所需的地址与认证信息，
+// 所有 List/Watch/Bind/Event 请求都通过同一个实例发出
+type Client struct {
+    server      *url.URL
+    bearerToken string
+    tokenFile   string
+    username    string
+    password    string
+    httpClient  *http.Client
+}
+
+// DefaultClient 沿用 kubectl proxy 的地址，不做认证
+func DefaultClient() *Client {
+    return &Client{
+        server:     &url.URL{Scheme: "http", Host: proxyHost},
+        httpClient: http.DefaultClient,
+    }
+}
+
+// NewClientForServer 使用给定地址、不做认证，主要用于测试
+func NewClientForServer(server *url.URL) *Client {
+    return &Client{server: server, httpClient: http.DefaultClient}
+}
+
+// Server 返回 apiserver 地址
+func (c *Client) Server() *url.URL {
+    return c.server
+}
+
+// kubeconfig 文件中用到的字段
+type kubeconfig struct {
+    CurrentContext string         `json:"current-context"`
+    Clusters       []namedCluster `json:"clusters"`
+    Contexts       []namedContext `json:"contexts"`
+    Users          []namedUser    `json:"users"`
+}
+
+type namedCluster struct {
+    Name    string `json:"name"`
+    Cluster struct {
+        Server                   string `json:"server"`
+        CertificateAuthority     string `json:"certificate-authority"`
+        CertificateAuthorityData string `json:"certificate-authority-data"`
+        InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+        TLSServerName            string `json:"tls-server-name"`
+    } `json:"cluster"`
+}
+
+type namedContext struct {
+    Name    string `json:"name"`
+    Context struct {
+        Cluster string `json:"cluster"`
+        User    string `json:"user"`
+    } `json:"context"`
+}
+
+type namedUser struct {
+    Name string `json:"name"`
+    User struct {
+        ClientCertificate     string `json:"client-certificate"`
+        ClientCertificateData string `json:"client-certificate-data"`
+        ClientKey             string `json:"client-key"`
+        ClientKeyData         string `json:"client-key-data"`
+        Token                 string `json:"token"`
+        TokenFile             string `json:"tokenFile"`
+        Username              string `json:"username"`
+        Password              string `json:"password"`
+        // 不支持通过外部命令或认证插件获取凭据，出现时报错而不是匿名访问
+        Exec         json.RawMessage `json:"exec"`
+        AuthProvider json.RawMessage `json:"auth-provider"`
+    } `json:"user"`
+}
+
+// NewClient 依次尝试 kubeconfig、集群内 ServiceAccount，最后退回到 kubectl proxy。
+// master 只替换所选配置中的 apiserver 地址，认证信息保持不变
+func NewClient(kubeconfigPath, context, master string) (*Client, error) {
+    if kubeconfigPath == "" {
+        for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+            if _, err := os.Stat(p); err == nil {
+                kubeconfigPath = p
+                break
+            }
+        }
+    }
+
+    var c *Client
+    var err error
+    switch {
+    case kubeconfigPath != "":
+        c, err = newKubeconfigClient(kubeconfigPath, context)
+    case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+        c, err = newInClusterClient()
+    default:
+        c = DefaultClient()
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if master != "" {
+        server, err := parseServer(master, c.tlsConfig())
+        if err != nil {
+            return nil, err
+        }
+        c.server = server
+    }
+    return c, nil
+}
+
+func newInClusterClient() (*Client, error) {
+    host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+    if host == "" || port == "" {
+        return nil, errors.New("unable to load in-cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
+    }
+
+    tokenFile := filepath.Join(serviceAccountDir, "token")
+    if _, err := os.Stat(tokenFile); err != nil {
+        return nil, err
+    }
+
+    ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+    if err != nil {
+        return nil, err
+    }
+    tlsConfig := &tls.Config{}
+    if tlsConfig.RootCAs, err = certPool(ca); err != nil {
+        return nil, err
+    }
+
+    return &Client{
+        server:     &url.URL{Scheme: "https", Host: net.JoinHostPort(host, port)},
+        tokenFile:  tokenFile,
+        httpClient: newHTTPClient(tlsConfig),
+    }, nil
+}
+
+func newKubeconfigClient(path, context string) (*Client, error) {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var config kubeconfig
+    if err := UnmarshalYAML(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse kubeconfig %s: %v", path, err)
+    }
+
+    if context == "" {
+        context = config.CurrentContext
+    }
+    var ctx *namedContext
+    for i := range config.Contexts {
+        if config.Contexts[i].Name == context {
+            ctx = &config.Contexts[i]
+        }
+    }
+    if ctx == nil {
+        return nil, fmt.Errorf("context %q not found in kubeconfig %s", context, path)
+    }
+
+    var cluster *namedCluster
+    for i := range config.Clusters {
+        if config.Clusters[i].Name == ctx.Context.Cluster {
+            cluster = &config.Clusters[i]
+        }
+    }
+    if cluster == nil {
+        return nil, fmt.Errorf("cluster %q not found in kubeconfig %s", ctx.Context.Cluster, path)
+    }
+
+    // kubeconfig 中的相对路径以文件所在目录为基准
+    dir := filepath.Dir(path)
+    resolve := func(p string) string {
+        if p == "" || filepath.IsAbs(p) {
+            return p
+        }
+        return filepath.Join(dir, p)
+    }
+
+    tlsConfig := &tls.Config{
+        InsecureSkipVerify: cluster.Cluster.InsecureSkipTLSVerify,
+        ServerName:         cluster.Cluster.TLSServerName,
+    }
+    ca, err := dataOrFile(cluster.Cluster.CertificateAuthorityData, resolve(cluster.Cluster.CertificateAuthority))
+    if err != nil {
+        return nil, err
+    }
+    if len(ca) > 0 {
+        if tlsConfig.RootCAs, err = certPool(ca); err != nil {
+            return nil, err
+        }
+    }
+
+    c := &Client{}
+    for _, u := range config.Users {
+        if u.Name != ctx.Context.User {
+            continue
+        }
+        if present(u.User.Exec) || present(u.User.AuthProvider) {
+            return nil, fmt.Errorf("user %q in kubeconfig %s uses exec or auth-provider credentials, which are not supported; use a token, client certificate or basic auth", u.Name, path)
+        }
+        cert, err := dataOrFile(u.User.ClientCertificateData, resolve(u.User.ClientCertificate))
+        if err != nil {
+            return nil, err
+        }
+        key, err := dataOrFile(u.User.ClientKeyData, resolve(u.User.ClientKey))
+        if err != nil {
+            return nil, err
+        }
+        if len(cert) > 0 || len(key) > 0 {
+            pair, err := tls.X509KeyPair(cert, key)
+            if err != nil {
+                return nil, fmt.Errorf("failed to load client certificate: %v", err)
+            }
+            tlsConfig.Certificates = []tls.Certificate{pair}
+        }
+        c.bearerToken = u.User.Token
+        c.tokenFile = resolve(u.User.TokenFile)
+        c.username = u.User.Username
+        c.password = u.User.Password
+    }
+
+    if c.server, err = parseServer(cluster.Cluster.Server, tlsConfig); err != nil {
+        return nil, err
+    }
+    c.httpClient = newHTTPClient(tlsConfig)
+    return c, nil
+}
+
+func present(raw json.RawMessage) bool {
+    return len(raw) > 0 && string(raw) != "null"
+}
+
+// parseServer 解析 apiserver 地址。未写协议时与 client-go 一致：
+// 配置了 CA、客户端证书或跳过证书校验时使用 https，否则使用 http（如 kubectl proxy）
+func parseServer(server string, tlsConfig *tls.Config) (*url.URL, error) {
+    if !strings.Contains(server, "://") {
+        scheme := "http"
+        if tlsConfig != nil && (tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) > 0 || tlsConfig.InsecureSkipVerify) {
+            scheme = "https"
+        }
+        server = scheme + "://" + server
+    }
+    u, err := url.Parse(server)
+    if err != nil {
+        return nil, fmt.Errorf("invalid API server address %q: %v", server, err)
+    }
+    return u, nil
+}
+
+// dataOrFile 优先使用 base64 编码的内联数据，否则读取文件
+func dataOrFile(data, file string) ([]byte, error) {
+    if data != "" {
+        return base64.StdEncoding.DecodeString(data)
+    }
+    if file != "" {
+        return ioutil.ReadFile(file)
+    }
+    return nil, nil
+}
+
+func certPool(ca []byte) (*x509.CertPool, error) {
+    pool := x509.NewCertPool()
+    if !pool.AppendCertsFromPEM(ca) {
+        return nil, errors.New("no valid certificates found in certificate authority data")
+    }
+    return pool, nil
+}
+
+// tlsConfig 返回客户端使用的 TLS 配置，未配置时返回 nil
+func (c *Client) tlsConfig() *tls.Config {
+    if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+        return transport.TLSClientConfig
+    }
+    return nil
+}
+
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.TLSClientConfig = tlsConfig
+    return &http.Client{Transport: transport}
+}
+
+// NewRequest 构造发往 apiserver 的请求，body 不为空时编码为 JSON
+func (c *Client) NewRequest(method, path string, query url.Values, body interface{}) (*http.Request, error) {
+    u := *c.server
+    u.Path = strings.TrimSuffix(u.Path, "/") + path
+    if query != nil {
+        u.RawQuery = query.Encode()
+    }
+
+    var reader io.Reader
+    if body != nil {
+        var b bytes.Buffer
+        if err := json.NewEncoder(&b).Encode(body); err != nil {
+            return nil, err
+        }
+        reader = &b
+    }
+
+    request, err := http.NewRequest(method, u.String(), reader)
+    if err != nil {
+        return nil, err
+    }
+    request.Header.Set("Accept", "application/json, */*")
+    if body != nil {
+        request.Header.Set("Content-Type", "application/json")
+    }
+
+    // ServiceAccount 的 token 会定期轮换，每次请求都重新读取
+    token := c.bearerToken
+    if c.tokenFile != "" {
+        b, err := ioutil.ReadFile(c.tokenFile)
+        if err != nil {
+            return nil, err
+        }
+        token = strings.TrimSpace(string(b))
+    }
+    switch {
+    case token != "":
+        request.Header.Set("Authorization", "Bearer "+token)
+    case c.username != "":
+        request.SetBasicAuth(c.username, c.password)
+    }
+    return request, nil
+}
+
+func (c *Client) Do(request *http.Request) (*http.Response, error) {
+    return c.httpClient.Do(request)
+}
+
+// Get 请求 path 并把返回的 JSON 解码到 v
+func (c *Client) Get(path string, query url.Values, v interface{}) error {
+    request, err := c.NewRequest(http.MethodGet, path, query, nil)
+    if err != nil {
+        return err
+    }
+    resp, err := c.Do(request)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return errors.New("Unexpected HTTP status code " + resp.Status)
+    }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Post 提交 body 并要求 apiserver 返回 201 Created
+func (c *Client) Post(path string, body interface{}) error {
+    request, err := c.NewRequest(http.MethodPost, path, nil, body)
+    if err != nil {
+        return err
+    }
+    resp, err := c.Do(request)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusCreated {
+        return errors.New("Unexpected HTTP status code " + resp.Status)
+    }
+    return nil
+}
+
+// Patch 以 JSON merge patch 方式修改 path 指向的对象
+func (c *Client) Patch(path string, body interface{}) error {
+    request, err := c.NewRequest(http.MethodPatch, path, nil, body)
+    if err != nil {
+        return err
+    }
+    request.Header.Set("Content-Type", "application/merge-patch+json")
+    resp, err := c.Do(request)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return errors.New("Unexpected HTTP status code " + resp.Status)
+    }
+    return nil
+}
@@ -0,0 +1,99 @@
+package kube
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func writeKubeconfig(t *testing.T, user string) string {
+    dir, err := ioutil.TempDir("", "kubeconfig")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { os.RemoveAll(dir) })
+
+    path := filepath.Join(dir, "config")
+    config := `apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: https://10.0.0.1:6443
+    insecure-skip-tls-verify: true
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+` + user
+    if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestNewClientKubeconfigToken(t *testing.T) {
+    path := writeKubeconfig(t, "    token: abc\n")
+    c, err := NewClient(path, "", "https://10.0.0.2:6443")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if c.Server().Host != "10.0.0.2:6443" {
+        t.Errorf("server = %s, want the -master override", c.Server())
+    }
+    request, err := c.NewRequest("GET", NodesEndpoint, nil, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := request.Header.Get("Authorization"); got != "Bearer abc" {
+        t.Errorf("Authorization = %q, want the kubeconfig token to survive -master", got)
+    }
+}
+
+func TestNewClientRejectsExecAndAuthProvider(t *testing.T) {
+    for name, user := range map[string]string{
+        "exec":          "    exec:\n      command: aws\n",
+        "auth-provider": "    auth-provider:\n      name: gcp\n",
+    } {
+        path := writeKubeconfig(t, user)
+        _, err := NewClient(path, "", "")
+        if err == nil || !strings.Contains(err.Error(), "not supported") {
+            t.Errorf("%s: err = %v, want an unsupported credentials error", name, err)
+        }
+    }
+}
+
+// 未写协议的地址只在配置了 TLS 时使用 https，kubectl proxy 的地址仍走 http
+func TestNewClientMasterScheme(t *testing.T) {
+    t.Setenv("KUBECONFIG", "")
+    t.Setenv("KUBERNETES_SERVICE_HOST", "")
+    kubeconfig := writeKubeconfig(t, "    token: abc\n")
+
+    tests := []struct {
+        kubeconfig string
+        master     string
+        want       string
+    }{
+        {"", "127.0.0.1:8080", "http://127.0.0.1:8080"},
+        {"", "https://127.0.0.1:8443", "https://127.0.0.1:8443"},
+        {kubeconfig, "10.0.0.2:6443", "https://10.0.0.2:6443"},
+        {kubeconfig, "http://127.0.0.1:8080", "http://127.0.0.1:8080"},
+    }
+    for _, tt := range tests {
+        c, err := NewClient(tt.kubeconfig, "", tt.master)
+        if err != nil {
+            t.Errorf("NewClient(%q, %q) error: %v", tt.kubeconfig, tt.master, err)
+            continue
+        }
+        if got := c.Server().String(); got != tt.want {
+            t.Errorf("NewClient(%q, %q) server = %s, want %s", tt.kubeconfig, tt.master, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,463 @@
+package kube
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// 仓库只依赖标准库，这里实现一个够用的 YAML 子集解析器：
+// 块状的 map / 列表、单行标量、引号字符串以及简单的 [] {} 行内写法，
+// 足以读取 kubeconfig 这类配置文件。解析结果按目标类型调整标量后转成 JSON，再解码到结构体上。
+
+type yamlLine struct {
+    num    int
+    indent int
+    text   string
+}
+
+type yamlParser struct {
+    lines []yamlLine
+    pos   int
+}
+
+// yamlPlain 是未加引号的数字或布尔标量。解码到字符串字段时保留原文，
+// 例如 token: 12345，其余情况使用 value
+type yamlPlain struct {
+    text  string
+    value interface{}
+}
+
+var (
+    yamlNumberPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+    jsonUnmarshaler   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// UnmarshalYAML 把 YAML（或 JSON）文档解码到 v
+func UnmarshalYAML(data []byte, v interface{}) error {
+    trimmed := strings.TrimSpace(string(data))
+    if strings.HasPrefix(trimmed, "{") {
+        return json.Unmarshal(data, v)
+    }
+
+    obj, err := parseYAML(data)
+    if err != nil {
+        return err
+    }
+    b, err := json.Marshal(coerceYAML(obj, reflect.TypeOf(v)))
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(b, v)
+}
+
+func parseYAML(data []byte) (interface{}, error) {
+    p := &yamlParser{}
+    for i, raw := range strings.Split(string(data), "\n") {
+        raw = strings.TrimRight(raw, " \t\r")
+        text := stripYAMLComment(strings.TrimLeft(raw, " "))
+        if text == "" || text == "---" {
+            continue
+        }
+        if strings.HasPrefix(raw, "\t") {
+            return nil, fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+        }
+        p.lines = append(p.lines, yamlLine{
+            num:    i + 1,
+            indent: len(raw) - len(strings.TrimLeft(raw, " ")),
+            text:   text,
+        })
+    }
+    if len(p.lines) == 0 {
+        return nil, nil
+    }
+
+    v, err := p.parseBlock()
+    if err != nil {
+        return nil, err
+    }
+    if p.pos < len(p.lines) {
+        return nil, fmt.Errorf("yaml: line %d: unexpected content", p.lines[p.pos].num)
+    }
+    return v, nil
+}
+
+func (p *yamlParser) parseBlock() (interface{}, error) {
+    l := p.lines[p.pos]
+    if isYAMLSeqItem(l.text) {
+        return p.parseSeq(l.indent)
+    }
+    return p.parseMap(l.indent)
+}
+
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+    items := make([]interface{}, 0)
+    for p.pos < len(p.lines) {
+        l := p.lines[p.pos]
+        if l.indent < indent || (l.indent == indent && !isYAMLSeqItem(l.text)) {
+            break
+        }
+        if l.indent > indent {
+            return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.num)
+        }
+
+        rest := strings.TrimLeft(l.text[1:], " ")
+        if rest == "" {
+            // "-" 单独成行，元素内容在下一层缩进中
+            p.pos++
+            item, err := p.parseChild(indent)
+            if err != nil {
+                return nil, err
+            }
+            items = append(items, item)
+            continue
+        }
+
+        if _, _, ok := splitYAMLMapping(rest); ok || isYAMLSeqItem(rest) {
+            // "- key: value" 视为一个缩进到 key 所在列的块
+            p.lines[p.pos] = yamlLine{
+                num:    l.num,
+                indent: l.indent + len(l.text) - len(rest),
+                text:   rest,
+            }
+            item, err := p.parseBlock()
+            if err != nil {
+                return nil, err
+            }
+            items = append(items, item)
+            continue
+        }
+
+        item, err := yamlScalar(rest)
+        if err != nil {
+            return nil, fmt.Errorf("yaml: line %d: %v", l.num, err)
+        }
+        items = append(items, item)
+        p.pos++
+    }
+    return items, nil
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+    m := make(map[string]interface{})
+    for p.pos < len(p.lines) {
+        l := p.lines[p.pos]
+        if l.indent < indent || isYAMLSeqItem(l.text) {
+            break
+        }
+        if l.indent > indent {
+            return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.num)
+        }
+
+        key, value, ok := splitYAMLMapping(l.text)
+        if !ok {
+            return nil, fmt.Errorf("yaml: line %d: expected a mapping entry", l.num)
+        }
+        p.pos++
+
+        if value == "" {
+            child, err := p.parseChild(indent)
+            if err != nil {
+                return nil, err
+            }
+            m[key] = child
+            continue
+        }
+        if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+            return nil, fmt.Errorf("yaml: line %d: block scalars are not supported", l.num)
+        }
+
+        v, err := yamlScalar(value)
+        if err != nil {
+            return nil, fmt.Errorf("yaml: line %d: %v", l.num, err)
+        }
+        m[key] = v
+    }
+    return m, nil
+}
+
+// parseChild 解析 "key:" 之后的值：更深一层的块，或与 key 同列的列表
+func (p *yamlParser) parseChild(indent int) (interface{}, error) {
+    if p.pos >= len(p.lines) {
+        return nil, nil
+    }
+    next := p.lines[p.pos]
+    if next.indent > indent {
+        return p.parseBlock()
+    }
+    if next.indent == indent && isYAMLSeqItem(next.text) {
+        return p.parseSeq(indent)
+    }
+    return nil, nil
+}
+
+func isYAMLSeqItem(text string) bool {
+    return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLMapping 在引号之外寻找 "key: value" 分隔符
+func splitYAMLMapping(text string) (string, string, bool) {
+    var quote byte
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+        switch {
+        case quote != 0:
+            i, quote = skipYAMLQuoted(text, i, quote)
+        case (c == '"' || c == '\'') && i == 0:
+            quote = c
+        case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+            key := strings.TrimSpace(text[:i])
+            if k, err := yamlScalar(key); err == nil {
+                if s, ok := k.(string); ok {
+                    key = s
+                }
+            }
+            return key, strings.TrimSpace(text[i+1:]), true
+        case c == '[' || c == '{':
+            if i == 0 {
+                return "", "", false
+            }
+        }
+    }
+    return "", "", false
+}
+
+func stripYAMLComment(text string) string {
+    var quote byte
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+        switch {
+        case quote != 0:
+            i, quote = skipYAMLQuoted(text, i, quote)
+        case (c == '"' || c == '\'') && opensYAMLQuote(text, i):
+            quote = c
+        case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+            return strings.TrimRight(text[:i], " \t")
+        }
+    }
+    return text
+}
+
+// opensYAMLQuote 判断 text[i] 处的引号是否开始一个带引号的标量：
+// 位于行首、"key: " 或 "- " 之后、行内写法的 [ { , 之后。
+// 普通标量中间的引号（如 don't）只是字符本身
+func opensYAMLQuote(text string, i int) bool {
+    before := strings.TrimRight(text[:i], " ")
+    if before == "" {
+        return true
+    }
+    spaced := len(before) < i
+    switch before[len(before)-1] {
+    case '[', '{', ',':
+        return true
+    case ':':
+        return spaced
+    case '-':
+        return spaced && strings.Trim(before, "- ") == ""
+    }
+    return false
+}
+
+// skipYAMLQuoted 处理引号内 text[i] 处的字符，返回处理到的位置以及之后是否仍在引号内。
+// 双引号中 \ 转义下一个字符，单引号中 '' 表示一个单引号
+func skipYAMLQuoted(text string, i int, quote byte) (int, byte) {
+    c := text[i]
+    switch {
+    case quote == '"' && c == '\\':
+        return i + 1, quote
+    case c == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+        return i + 1, quote
+    case c == quote:
+        return i, 0
+    }
+    return i, quote
+}
+
+func yamlScalar(s string) (interface{}, error) {
+    switch {
+    case strings.HasPrefix(s, "\""):
+        return strconv.Unquote(s)
+    case strings.HasPrefix(s, "'"):
+        if len(s) < 2 || !strings.HasSuffix(s, "'") {
+            return nil, fmt.Errorf("unterminated string %s", s)
+        }
+        return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+    case strings.HasPrefix(s, "["):
+        if !strings.HasSuffix(s, "]") {
+            return nil, fmt.Errorf("unterminated flow sequence %s", s)
+        }
+        items := make([]interface{}, 0)
+        for _, field := range splitYAMLFlow(s[1 : len(s)-1]) {
+            v, err := yamlScalar(field)
+            if err != nil {
+                return nil, err
+            }
+            items = append(items, v)
+        }
+        return items, nil
+    case strings.HasPrefix(s, "{"):
+        if !strings.HasSuffix(s, "}") {
+            return nil, fmt.Errorf("unterminated flow mapping %s", s)
+        }
+        m := make(map[string]interface{})
+        for _, field := range splitYAMLFlow(s[1 : len(s)-1]) {
+            key, value, ok := splitYAMLMapping(field)
+            if !ok {
+                return nil, fmt.Errorf("invalid flow mapping entry %s", field)
+            }
+            v, err := yamlScalar(value)
+            if err != nil {
+                return nil, err
+            }
+            m[key] = v
+        }
+        return m, nil
+    }
+
+    switch s {
+    case "", "~", "null", "Null", "NULL":
+        return nil, nil
+    case "true", "True", "TRUE":
+        return yamlPlain{text: s, value: true}, nil
+    case "false", "False", "FALSE":
+        return yamlPlain{text: s, value: false}, nil
+    }
+    if yamlNumberPattern.MatchString(s) {
+        return yamlPlain{text: s, value: yamlJSONNumber(s)}, nil
+    }
+    return s, nil
+}
+
+// yamlJSONNumber 把 YAML 数字改写为合法的 JSON 数字，如 +1、.5、5.、007
+func yamlJSONNumber(s string) json.Number {
+    sign := ""
+    if s[0] == '-' || s[0] == '+' {
+        if s[0] == '-' {
+            sign = "-"
+        }
+        s = s[1:]
+    }
+    mantissa, exponent := s, ""
+    if i := strings.IndexAny(s, "eE"); i >= 0 {
+        mantissa, exponent = s[:i], s[i:]
+    }
+    integer, fraction := mantissa, ""
+    if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+        integer, fraction = mantissa[:i], mantissa[i+1:]
+    }
+    integer = strings.TrimLeft(integer, "0")
+    if integer == "" {
+        integer = "0"
+    }
+    number := sign + integer
+    if fraction != "" {
+        number += "." + fraction
+    }
+    return json.Number(number + exponent)
+}
+
+// coerceYAML 按目标类型 t 确定 yamlPlain 的取值；t 为 nil 表示目标类型未知，
+// 如 interface{} 或 json.RawMessage，此时一律使用 JSON 值
+func coerceYAML(v interface{}, t reflect.Type) interface{} {
+    for t != nil && t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    if t != nil && (t.Kind() == reflect.Interface || reflect.PtrTo(t).Implements(jsonUnmarshaler)) {
+        t = nil
+    }
+
+    switch v := v.(type) {
+    case yamlPlain:
+        if t != nil && t.Kind() == reflect.String {
+            return v.text
+        }
+        return v.value
+    case []interface{}:
+        var elem reflect.Type
+        if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+            elem = t.Elem()
+        }
+        for i := range v {
+            v[i] = coerceYAML(v[i], elem)
+        }
+    case map[string]interface{}:
+        for key, value := range v {
+            var elem reflect.Type
+            if t != nil && t.Kind() == reflect.Map {
+                elem = t.Elem()
+            } else if t != nil && t.Kind() == reflect.Struct {
+                elem = yamlFieldType(t, key)
+            }
+            v[key] = coerceYAML(value, elem)
+        }
+    }
+    return v
+}
+
+// yamlFieldType 按 encoding/json 的规则查找 key 对应的结构体字段类型：
+// 优先精确匹配 json tag 或字段名，其次忽略大小写，匿名字段展开查找
+func yamlFieldType(t reflect.Type, key string) reflect.Type {
+    var folded reflect.Type
+    for i := 0; i < t.NumField(); i++ {
+        f := t.Field(i)
+        name := strings.Split(f.Tag.Get("json"), ",")[0]
+        if name == "-" {
+            continue
+        }
+        if f.Anonymous && name == "" {
+            ft := f.Type
+            if ft.Kind() == reflect.Ptr {
+                ft = ft.Elem()
+            }
+            if ft.Kind() == reflect.Struct {
+                if found := yamlFieldType(ft, key); found != nil {
+                    return found
+                }
+                continue
+            }
+        }
+        if f.PkgPath != "" {
+            continue
+        }
+        if name == "" {
+            name = f.Name
+        }
+        if name == key {
+            return f.Type
+        }
+        if folded == nil && strings.EqualFold(name, key) {
+            folded = f.Type
+        }
+    }
+    return folded
+}
+
+// splitYAMLFlow 按逗号切分行内写法，忽略嵌套括号和引号中的逗号
+func splitYAMLFlow(s string) []string {
+    var fields []string
+    var quote byte
+    depth, start := 0, 0
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case quote != 0:
+            i, quote = skipYAMLQuoted(s, i, quote)
+        case (c == '"' || c == '\'') && opensYAMLQuote(s[start:], i-start):
+            quote = c
+        case c == '[' || c == '{':
+            depth++
+        case c == ']' || c == '}':
+            depth--
+        case c == ',' && depth == 0:
+            fields = append(fields, strings.TrimSpace(s[start:i]))
+            start = i + 1
+        }
+    }
+    if last := strings.TrimSpace(s[start:]); last != "" {
+        fields = append(fields, last)
+    }
+    return fields
+}
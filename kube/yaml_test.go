@@ -0,0 +1,136 @@
+package kube
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+func TestUnmarshalYAMLScalars(t *testing.T) {
+    type target struct {
+        Name     string            `json:"name"`
+        Token    string            `json:"token"`
+        Password string            `json:"password"`
+        Enabled  bool              `json:"enabled"`
+        Ratio    float64           `json:"ratio"`
+        Count    int64             `json:"count"`
+        Tags     []string          `json:"tags"`
+        Labels   map[string]string `json:"labels"`
+        Args     json.RawMessage   `json:"args"`
+    }
+    tests := []struct {
+        name string
+        in   string
+        want target
+    }{
+        {"number into string", "token: 12345", target{Token: "12345"}},
+        {"leading zeros kept", "password: 007", target{Password: "007"}},
+        {"bool into string", "name: true", target{Name: "true"}},
+        {"bool", "enabled: true", target{Enabled: true}},
+        {"leading dot", "ratio: .5", target{Ratio: 0.5}},
+        {"trailing dot", "ratio: 5.", target{Ratio: 5}},
+        {"plus sign", "count: +3", target{Count: 3}},
+        {"exponent", "ratio: 1e-3", target{Ratio: 0.001}},
+        {"hex is a string", "name: 0x1F", target{Name: "0x1F"}},
+        {"null", "name: ~", target{}},
+        {"apostrophe in plain scalar", "name: don't # note", target{Name: "don't"}},
+        {"hash without space", "name: a#b", target{Name: "a#b"}},
+        {"hash in double quotes", `name: "a # b" # note`, target{Name: "a # b"}},
+        {"escaped double quote", `name: "say \"hi\" # x" # note`, target{Name: `say "hi" # x`}},
+        {"escaped single quote", "name: 'it''s # x' # note", target{Name: "it's # x"}},
+        {"numbers in string list", "tags: [1, '2', x]", target{Tags: []string{"1", "2", "x"}}},
+        {"block list", "tags:\n- 1\n- don't", target{Tags: []string{"1", "don't"}}},
+        {"numbers in string map", "labels: {zone: 1, rack: \"a,b\"}", target{Labels: map[string]string{"zone": "1", "rack": "a,b"}}},
+        {"raw args", "args:\n  shape:\n  - utilization: .5\n    score: 10", target{Args: json.RawMessage(`{"shape":[{"score":10,"utilization":0.5}]}`)}},
+        {"json document", `{"token": "abc"}`, target{Token: "abc"}},
+    }
+    for _, tt := range tests {
+        var got target
+        if err := UnmarshalYAML([]byte(tt.in), &got); err != nil {
+            t.Errorf("%s: UnmarshalYAML(%q) error: %v", tt.name, tt.in, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: UnmarshalYAML(%q) = %+v, want %+v", tt.name, tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestUnmarshalYAMLErrors(t *testing.T) {
+    for _, in := range []string{
+        "a:\n\tb: c",
+        "a: |\n  text",
+        "a: 'open",
+        "a: [1, 2",
+        "a: b\n    c: d",
+        "a:\n  - b\n  c: d",
+    } {
+        var v interface{}
+        if err := UnmarshalYAML([]byte(in), &v); err == nil {
+            t.Errorf("UnmarshalYAML(%q) = %v, want error", in, v)
+        }
+    }
+}
+
+// kubectl 生成的列表与 key 同列，手写的文件常常再缩进一层
+func TestUnmarshalYAMLKubeconfig(t *testing.T) {
+    tests := []struct {
+        name string
+        in   string
+    }{
+        {"kubectl", `apiVersion: v1
+kind: Config
+current-context: dev # 当前使用
+clusters:
+- cluster:
+    certificate-authority-data: Q0E=
+    server: https://10.0.0.1:6443
+  name: dev
+contexts:
+- context:
+    cluster: dev
+    user: admin
+  name: dev
+preferences: {}
+users:
+- name: admin
+  user:
+    token: 12345
+`},
+        {"indented lists", `current-context: "dev"
+clusters:
+  - name: dev
+    cluster:
+      server: 'https://10.0.0.1:6443'
+      certificate-authority-data: Q0E=
+contexts:
+  -
+    name: dev
+    context: {cluster: dev, user: admin}
+users:
+  - name: admin
+    user:
+      token: "12345"
+`},
+    }
+    for _, tt := range tests {
+        var config kubeconfig
+        if err := UnmarshalYAML([]byte(tt.in), &config); err != nil {
+            t.Errorf("%s: %v", tt.name, err)
+            continue
+        }
+        if config.CurrentContext != "dev" || len(config.Clusters) != 1 || len(config.Contexts) != 1 || len(config.Users) != 1 {
+            t.Errorf("%s: kubeconfig = %+v", tt.name, config)
+            continue
+        }
+        if c := config.Clusters[0]; c.Name != "dev" || c.Cluster.Server != "https://10.0.0.1:6443" || c.Cluster.CertificateAuthorityData != "Q0E=" {
+            t.Errorf("%s: cluster = %+v", tt.name, c)
+        }
+        if c := config.Contexts[0]; c.Name != "dev" || c.Context.Cluster != "dev" || c.Context.User != "admin" {
+            t.Errorf("%s: context = %+v", tt.name, c)
+        }
+        if u := config.Users[0]; u.Name != "admin" || u.User.Token != "12345" {
+            t.Errorf("%s: user = %+v", tt.name, u)
+        }
+    }
+}